@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// auditRecord is a line of the audit log, recording a run of Terraform. The
+// arguments are hashed as they may hold secrets, such as -var values.
+type auditRecord struct {
+	Time       string `json:"time"`
+	Version    string `json:"version"`
+	Source     string `json:"source"`
+	WorkingDir string `json:"working_dir"`
+	ArgsSHA256 string `json:"args_sha256"`
+}
+
+// audit appends a record of running tfVersion with args to the audit log,
+// when enabled with the audit_log setting. The file is closed before
+// returning so the record is on disk before Terraform replaces tvm. Failing
+// to write it is only reported.
+func audit(tfVersion *version.Version, source string, args []string) {
+	if !cfg.AuditLog {
+		return
+	}
+
+	argsHash := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+
+	record := auditRecord{
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Version:    tfVersion.String(),
+		Source:     source,
+		WorkingDir: workingDir(),
+		ArgsSHA256: hex.EncodeToString(argsHash[:]),
+	}
+
+	if err := appendAuditRecord(auditLogPath(), record); err != nil {
+		warnf("Failed to write audit log: %s", err)
+	}
+}
+
+func auditLogPath() string {
+	if cfg.AuditLogPath != "" {
+		return cfg.AuditLogPath
+	}
+
+	return path.Join(dataDirPath, "audit.log")
+}
+
+func appendAuditRecord(auditLogPath string, record auditRecord) error {
+	var line []byte
+
+	switch cfg.AuditLogFormat {
+	case "", "json":
+		data, err := json.Marshal(record)
+
+		if err != nil {
+			return err
+		}
+
+		line = append(data, '\n')
+	case "text":
+		line = []byte(fmt.Sprintf("%s version=%s source=%q dir=%q args_sha256=%s\n", record.Time, record.Version, record.Source, record.WorkingDir, record.ArgsSHA256))
+	default:
+		return fmt.Errorf("Invalid audit_log_format %q, expected json or text", cfg.AuditLogFormat)
+	}
+
+	auditLogFile, err := os.OpenFile(auditLogPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := auditLogFile.Write(line); err != nil {
+		auditLogFile.Close()
+
+		return err
+	}
+
+	return auditLogFile.Close()
+}
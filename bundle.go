@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+type bundleOptions struct {
+	Versions []string
+	Platform string
+	Output   string
+}
+
+// bundle writes the versions for the platform to an archive, which
+// install --from-bundle imports on machines without network access.
+func bundle(opts bundleOptions) error {
+	if len(opts.Versions) == 0 || opts.Output == "" {
+		return fmt.Errorf("--versions and -o are required")
+	}
+
+	managerOpts := managerOptions()
+
+	if opts.Platform != "" {
+		platform := strings.SplitN(opts.Platform, "_", 2)
+
+		if len(platform) != 2 {
+			return fmt.Errorf("Invalid platform %q, expected os_arch", opts.Platform)
+		}
+
+		managerOpts.OS, managerOpts.Arch = platform[0], platform[1]
+	}
+
+	versions := make([]*version.Version, 0, len(opts.Versions))
+
+	for _, raw := range opts.Versions {
+		v, err := version.NewVersion(strings.TrimSpace(raw))
+
+		if err != nil {
+			return fmt.Errorf("Invalid version %q: %s", raw, err)
+		}
+
+		versions = append(versions, v)
+	}
+
+	bundleFile, err := os.Create(opts.Output)
+
+	if err != nil {
+		return err
+	}
+
+	err = tvm.New(managerOpts).Bundle(context.Background(), versions, bundleFile)
+
+	if closeErr := bundleFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		if err := os.Remove(opts.Output); err != nil {
+			fmt.Println("Error removing bundle")
+		}
+
+		return err
+	}
+
+	fmt.Printf("Bundled %d Terraform versions in %s\n", len(versions), opts.Output)
+
+	return nil
+}
+
+func importBundle(m *tvm.Manager, bundlePath string) error {
+	bundleFile, err := os.Open(bundlePath)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := bundleFile.Close(); err != nil {
+			fmt.Println("Error closing bundle")
+		}
+	}()
+
+	_, err = m.ImportBundle(bundleFile)
+
+	return err
+}
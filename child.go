@@ -0,0 +1,118 @@
+package main
+
+import (
+	"io"
+	"os"
+	osexec "os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// timeoutExitCode is the exit code of a Terraform run killed because it
+// exceeded its timeout, the one used by timeout(1).
+const timeoutExitCode = 124
+
+// timeoutGracePeriod is how long Terraform is given to exit after being
+// asked to on timeout, before it is killed.
+const timeoutGracePeriod = 10 * time.Second
+
+// runTerraform runs Terraform as a child process, instead of replacing tvm
+// with it, and returns its exit code. Interrupt and termination signals
+// received by tvm don't stop it: they are forwarded to the child when it runs
+// in its own process group, and dropped otherwise, as the terminal sends them
+// to the whole foreground group, the child included. Terraform would take a
+// second interrupt as a request to exit immediately.
+//
+// When timeout is not zero and Terraform runs longer than that, it is asked
+// to exit, then killed after timeoutGracePeriod, along with the providers it
+// started, and timeoutExitCode is returned. To that end Terraform runs in its
+// own process group, except when stdin is a terminal, where it must stay in
+// the foreground group to be able to prompt, and only Terraform itself is
+// killed.
+func runTerraform(binPath string, args []string, env []string, stderr io.Writer, timeout time.Duration) (int, error) {
+	cmd := osexec.Command(binPath, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = stderr
+
+	ownGroup := false
+
+	if timeout > 0 && !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		ownGroup = setProcessGroup(cmd)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	// The signals are caught rather than ignored, which the child would
+	// inherit.
+	go func() {
+		for sig := range signals {
+			if !ownGroup {
+				continue
+			}
+
+			if err := cmd.Process.Signal(sig); err != nil {
+				warnf("Error forwarding signal to Terraform")
+			}
+		}
+	}()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+	timedOut := false
+
+	if timeout > 0 {
+		select {
+		case err = <-done:
+		case <-time.After(timeout):
+			timedOut = true
+			warnf("Terraform did not finish within %s, stopping it", timeout)
+
+			if err := terminate(cmd, false); err != nil {
+				warnf("Error stopping Terraform")
+			}
+
+			select {
+			case err = <-done:
+			case <-time.After(timeoutGracePeriod):
+				if err := terminate(cmd, true); err != nil {
+					warnf("Error killing Terraform")
+				}
+
+				err = <-done
+			}
+		}
+	} else {
+		err = <-done
+	}
+
+	if timedOut {
+		return timeoutExitCode, nil
+	}
+
+	if exitErr, ok := err.(*osexec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return 0, nil
+}
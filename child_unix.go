@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	osexec "os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd the leader of a new process group, so that the
+// processes it starts can be terminated along with it, and reports that it
+// did.
+func setProcessGroup(cmd *osexec.Cmd) bool {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	return true
+}
+
+// terminate sends SIGTERM, or SIGKILL when force is set, to cmd and, if it
+// leads one, to its process group.
+func terminate(cmd *osexec.Cmd, force bool) error {
+	sig := syscall.SIGTERM
+
+	if force {
+		sig = syscall.SIGKILL
+	}
+
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+		return syscall.Kill(-cmd.Process.Pid, sig)
+	}
+
+	return cmd.Process.Signal(sig)
+}
@@ -0,0 +1,142 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestRunTerraformSignalsReachChildOnce starts tvm, as the parent helper
+// process, in its own process group standing for the foreground group of a
+// terminal, and interrupts the group as Ctrl-C does, or only tvm. The child
+// helper process, standing for Terraform, counts the interrupts it receives.
+// As the kernel merges signals which are pending together, an interrupt
+// forwarded along the one sent to the group could go unnoticed, hence the
+// cases interrupting only tvm.
+func TestRunTerraformSignalsReachChildOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		groupKill bool
+		want      string
+	}{
+		{"same process group", 0, true, "1"},
+		{"same process group, tvm only", 0, false, "0"},
+		{"own process group", time.Minute, true, "1"},
+		{"own process group, tvm only", time.Minute, false, "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			cmd := osexec.Command(os.Args[0], "-test.run=^TestSignalHelperProcess$")
+			cmd.Env = append(os.Environ(),
+				"TVM_TEST_SIGNAL_HELPER=parent",
+				"TVM_TEST_SIGNAL_DIR="+dir,
+				"TVM_TEST_SIGNAL_TIMEOUT="+tt.timeout.String(),
+			)
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+			if err := cmd.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			readyPath := filepath.Join(dir, "ready")
+
+			for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+				if _, err := os.Stat(readyPath); err == nil {
+					break
+				}
+
+				if time.Since(start) > 10*time.Second {
+					_ = cmd.Process.Kill()
+					t.Fatal("child helper process not ready")
+				}
+			}
+
+			pid := cmd.Process.Pid
+
+			if tt.groupKill {
+				pid = -pid
+			}
+
+			if err := syscall.Kill(pid, syscall.SIGINT); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := cmd.Wait(); err != nil {
+				t.Fatal(err)
+			}
+
+			count, err := ioutil.ReadFile(filepath.Join(dir, "count"))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(count) != tt.want {
+				t.Errorf("child received %s interrupts, want %s", count, tt.want)
+			}
+		})
+	}
+}
+
+// TestSignalHelperProcess is tvm running Terraform, with
+// TVM_TEST_SIGNAL_HELPER=parent, or Terraform, with child.
+func TestSignalHelperProcess(t *testing.T) {
+	dir := os.Getenv("TVM_TEST_SIGNAL_DIR")
+
+	switch os.Getenv("TVM_TEST_SIGNAL_HELPER") {
+	case "parent":
+		timeout, err := time.ParseDuration(os.Getenv("TVM_TEST_SIGNAL_TIMEOUT"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		env := append(os.Environ(), "TVM_TEST_SIGNAL_HELPER=child")
+		code, err := runTerraform(os.Args[0], []string{"-test.run=^TestSignalHelperProcess$"}, env, os.Stderr, timeout)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		os.Exit(code)
+	case "child":
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt)
+
+		if err := ioutil.WriteFile(filepath.Join(dir, "ready"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		count := 0
+
+		wait := time.After(time.Second)
+
+		for {
+			select {
+			case <-signals:
+				count++
+			case <-wait:
+				if err := ioutil.WriteFile(filepath.Join(dir, "count"), []byte(strconv.Itoa(count)), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				os.Exit(0)
+			}
+		}
+	default:
+		t.Skip("only run by TestRunTerraformSignalsReachChildOnce")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	osexec "os/exec"
+)
+
+// setProcessGroup does nothing on Windows, which has no process groups to
+// signal, so only Terraform itself is killed on timeout. Console interrupts
+// reach every process attached to the console there.
+func setProcessGroup(cmd *osexec.Cmd) bool {
+	return false
+}
+
+// terminate kills cmd, Windows having no way to ask a process to exit.
+func terminate(cmd *osexec.Cmd, force bool) error {
+	return cmd.Process.Kill()
+}
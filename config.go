@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// tvmConfig holds the settings read from the tvm configuration file and the
+// .tvmrc files of the project, which take precedence over it. Some of them
+// can be overridden with the matching TVM_* environment variable.
+type tvmConfig struct {
+	BaseURL     string `json:"base_url"`
+	AutoInstall bool   `json:"auto_install"`
+
+	InsecureSkipTLSVerify bool `json:"insecure_skip_tls_verify"`
+
+	IncludePrerelease    bool `json:"include_prerelease"`
+	IndexCacheTTLMinutes int  `json:"index_cache_ttl_minutes"`
+
+	PostInstall string `json:"post_install"`
+	StrictHooks bool   `json:"strict_hooks"`
+
+	RecursiveConstraints bool              `json:"recursive_constraints"`
+	ProviderMinTerraform map[string]string `json:"provider_min_terraform"`
+
+	Quiet          bool `json:"quiet"`
+	StateCheck     bool `json:"state_check"`
+	StrictState    bool `json:"strict_state"`
+	Advisories     bool `json:"advisories"`
+	SystemFallback bool `json:"system_fallback"`
+
+	RetryOnLock       int      `json:"retry_on_lock"`
+	RetryLockCommands []string `json:"retry_lock_commands"`
+
+	AuditLog       bool   `json:"audit_log"`
+	AuditLogPath   string `json:"audit_log_path"`
+	AuditLogFormat string `json:"audit_log_format"`
+
+	GCAuto              bool `json:"gc_auto"`
+	GCIndexMaxAgeHours  int  `json:"gc_index_max_age_hours"`
+	GCArchiveMaxAgeDays int  `json:"gc_archive_max_age_days"`
+	GCKeepVersions      int  `json:"gc_keep_versions"`
+	GCVersionMaxAgeDays int  `json:"gc_version_max_age_days"`
+
+	AllowedVersions []string `json:"allowed_versions"`
+	DeniedVersions  []string `json:"denied_versions"`
+	BlockDeniedExec bool     `json:"block_denied_exec"`
+
+	TrustedProjectDirs []string `json:"trusted_project_dirs"`
+}
+
+// projectConfigFileName is the name of the project configuration files, in
+// the same format as the configuration file.
+const projectConfigFileName = ".tvmrc"
+
+// globalOnlyKeys are the settings which are ignored in project configuration
+// files, as cloning a repository must neither run commands nor weaken the
+// policy or the security of the machine. This rules out choosing where
+// binaries come from and installing them unasked, removing installed
+// versions, retrying commands which change infrastructure and silencing
+// warnings. A warning is printed whenever one of them is ignored.
+var globalOnlyKeys = []string{
+	"base_url",
+	"auto_install",
+	"insecure_skip_tls_verify",
+	"post_install",
+	"allowed_versions",
+	"denied_versions",
+	"block_denied_exec",
+	"advisories",
+	"system_fallback",
+	"strict_state",
+	"retry_on_lock",
+	"retry_lock_commands",
+	"gc_auto",
+	"gc_index_max_age_hours",
+	"gc_archive_max_age_days",
+	"gc_keep_versions",
+	"gc_version_max_age_days",
+	"audit_log",
+	"audit_log_path",
+	"audit_log_format",
+	"trusted_project_dirs",
+}
+
+// trustedProjectKeys are the global only settings which the project
+// configuration files of the trusted_project_dirs, and of their
+// subdirectories, may set nonetheless, so that the repositories of a team can
+// select its mirror and have the versions they need installed.
+var trustedProjectKeys = []string{
+	"base_url",
+	"auto_install",
+}
+
+var (
+	configFilePath       string
+	providerMinTerraform map[string]*version.Version
+	allowedVersions      []version.Constraints
+	deniedVersions       []version.Constraints
+	cfg                  = tvmConfig{
+		BaseURL:              "https://releases.hashicorp.com/terraform/",
+		IndexCacheTTLMinutes: 60,
+		Advisories:           true,
+		GCIndexMaxAgeHours:   24,
+		GCArchiveMaxAgeDays:  7,
+		GCVersionMaxAgeDays:  30,
+		RetryLockCommands:    []string{"plan", "refresh", "output", "show"},
+	}
+)
+
+func loadConfig() error {
+	userConfigDirPath, err := os.UserConfigDir()
+
+	if err != nil {
+		return err
+	}
+
+	configFilePath = path.Join(userConfigDirPath, "tvm", "config.json")
+
+	configFile, err := os.Open(configFilePath)
+
+	if err == nil {
+		defer func() {
+			if err := configFile.Close(); err != nil {
+				fmt.Println("Error closing configuration file")
+			}
+		}()
+
+		if err := json.NewDecoder(configFile).Decode(&cfg); err != nil {
+			return fmt.Errorf("Failed to parse %s: %s", configFilePath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := loadProjectConfig(); err != nil {
+		return err
+	}
+
+	if baseURL, ok := os.LookupEnv("TVM_BASE_URL"); ok {
+		cfg.BaseURL = baseURL
+	}
+
+	if postInstall, ok := os.LookupEnv("TVM_POST_INSTALL"); ok {
+		cfg.PostInstall = postInstall
+	}
+
+	if retryOnLock, ok := os.LookupEnv("TVM_RETRY_LOCK"); ok {
+		n, err := strconv.Atoi(retryOnLock)
+
+		if err != nil {
+			return fmt.Errorf("Invalid value %q for TVM_RETRY_LOCK: %s", retryOnLock, err)
+		}
+
+		cfg.RetryOnLock = n
+	}
+
+	if recursiveConstraints, ok := lookupEnvBool("TVM_RECURSIVE_CONSTRAINTS"); ok {
+		cfg.RecursiveConstraints = recursiveConstraints
+	}
+
+	if autoInstall, ok := lookupEnvBool("TVM_AUTO_INSTALL"); ok {
+		cfg.AutoInstall = autoInstall
+	}
+
+	if advisories, ok := lookupEnvBool("TVM_ADVISORIES"); ok {
+		cfg.Advisories = advisories
+	}
+
+	if systemFallback, ok := lookupEnvBool("TVM_SYSTEM_FALLBACK"); ok {
+		cfg.SystemFallback = systemFallback
+	}
+
+	if stateCheck, ok := lookupEnvBool("TVM_STATE_CHECK"); ok {
+		cfg.StateCheck = stateCheck
+	}
+
+	if quiet, ok := lookupEnvBool("TVM_QUIET"); ok {
+		cfg.Quiet = quiet
+	}
+
+	if insecureSkipTLSVerify, ok := lookupEnvBool("TVM_INSECURE_SKIP_TLS_VERIFY"); ok {
+		cfg.InsecureSkipTLSVerify = insecureSkipTLSVerify
+	}
+
+	if auditLog, ok := lookupEnvBool("TVM_AUDIT_LOG"); ok {
+		cfg.AuditLog = auditLog
+	}
+
+	if gcAuto, ok := lookupEnvBool("TVM_GC_AUTO"); ok {
+		cfg.GCAuto = gcAuto
+	}
+
+	if allowedVersions, err = parseConstraintsList("allowed_versions", cfg.AllowedVersions); err != nil {
+		return err
+	}
+
+	if deniedVersions, err = parseConstraintsList("denied_versions", cfg.DeniedVersions); err != nil {
+		return err
+	}
+
+	if providerMinTerraform, err = parseVersionMap("provider_min_terraform", cfg.ProviderMinTerraform); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// loadProjectConfig merges the .tvmrc files of the current directory and its
+// parents into the configuration, the nearest one taking precedence.
+func loadProjectConfig() error {
+	dir := workingDir()
+	projectConfigFilePaths := make([]string, 0)
+
+	for {
+		projectConfigFilePaths = append(projectConfigFilePaths, filepath.Join(dir, projectConfigFileName))
+
+		parentDir := filepath.Dir(dir)
+
+		if parentDir == dir {
+			break
+		}
+
+		dir = parentDir
+	}
+
+	for i := len(projectConfigFilePaths) - 1; i >= 0; i-- {
+		if err := mergeProjectConfig(projectConfigFilePaths[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mergeProjectConfig(projectConfigFilePath string) error {
+	data, err := ioutil.ReadFile(projectConfigFilePath)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	values := map[string]json.RawMessage{}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("Failed to parse %s: %s", projectConfigFilePath, err)
+	}
+
+	trusted := isTrustedProjectDir(filepath.Dir(projectConfigFilePath))
+
+	for _, key := range globalOnlyKeys {
+		if _, ok := values[key]; !ok {
+			continue
+		}
+
+		if isTrustedProjectKey(key) {
+			if trusted {
+				continue
+			}
+
+			warnf("Ignoring %s in %s, it can only be set in %s or in the %s of a directory listed in trusted_project_dirs", key, projectConfigFilePath, configFilePath, projectConfigFileName)
+		} else {
+			warnf("Ignoring %s in %s, it can only be set in %s", key, projectConfigFilePath, configFilePath)
+		}
+
+		delete(values, key)
+	}
+
+	data, err = json.Marshal(values)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("Failed to parse %s: %s", projectConfigFilePath, err)
+	}
+
+	return nil
+}
+
+// isTrustedProjectDir reports whether dir is one of the trusted_project_dirs
+// or below one of them. Relative directories are never trusted.
+func isTrustedProjectDir(dir string) bool {
+	for _, trustedDir := range cfg.TrustedProjectDirs {
+		if !filepath.IsAbs(trustedDir) {
+			continue
+		}
+
+		trustedDir = filepath.Clean(trustedDir)
+
+		if dir == trustedDir || strings.HasPrefix(dir, strings.TrimSuffix(trustedDir, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTrustedProjectKey(key string) bool {
+	for _, trustedKey := range trustedProjectKeys {
+		if key == trustedKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseConstraintsList(key string, values []string) ([]version.Constraints, error) {
+	constraintsList := make([]version.Constraints, 0, len(values))
+
+	for _, value := range values {
+		constraints, err := version.NewConstraint(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid constraint %q in %s: %s", value, key, err)
+		}
+
+		constraintsList = append(constraintsList, constraints)
+	}
+
+	return constraintsList, nil
+}
+
+func parseVersionMap(key string, values map[string]string) (map[string]*version.Version, error) {
+	versions := make(map[string]*version.Version, len(values))
+
+	for name, value := range values {
+		v, err := version.NewVersion(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid version %q for %s in %s: %s", value, name, key, err)
+		}
+
+		versions[name] = v
+	}
+
+	return versions, nil
+}
+
+// commaSeparatedValue is a flag.Value for flags taking a comma separated list.
+type commaSeparatedValue struct {
+	values *[]string
+}
+
+func (v commaSeparatedValue) String() string {
+	if v.values == nil {
+		return ""
+	}
+
+	return strings.Join(*v.values, ",")
+}
+
+func (v commaSeparatedValue) Set(s string) error {
+	*v.values = strings.Split(s, ",")
+
+	return nil
+}
+
+func lookupEnvBool(key string) (bool, bool) {
+	value, ok := os.LookupEnv(key)
+
+	if !ok {
+		return false, false
+	}
+
+	b, err := strconv.ParseBool(value)
+
+	if err != nil {
+		fmt.Printf("Ignoring invalid value %q for %s\n", value, key)
+
+		return false, false
+	}
+
+	return b, true
+}
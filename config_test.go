@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// chdir changes the current directory to dir for the duration of the test,
+// $PWD included as workingDir prefers it.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	previousDir, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := os.Chdir(previousDir); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Setenv("PWD", dir)
+}
+
+// useConfig replaces the configuration for the duration of the test.
+func useConfig(t *testing.T, c tvmConfig) {
+	t.Helper()
+
+	previousCfg := cfg
+	cfg = c
+
+	t.Cleanup(func() {
+		cfg = previousCfg
+	})
+}
+
+func writeProjectConfig(t *testing.T, dir string, values map[string]interface{}) {
+	t.Helper()
+
+	data, err := json.Marshal(values)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, projectConfigFileName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadProjectConfigPrecedence(t *testing.T) {
+	rootDir := t.TempDir()
+	childDir := filepath.Join(rootDir, "child")
+
+	if err := os.Mkdir(childDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeProjectConfig(t, rootDir, map[string]interface{}{
+		"include_prerelease":      true,
+		"index_cache_ttl_minutes": 5,
+		"quiet":                   true,
+	})
+	writeProjectConfig(t, childDir, map[string]interface{}{
+		"index_cache_ttl_minutes": 10,
+		"quiet":                   false,
+		"base_url":                "https://mirror.example.com/terraform/",
+		"auto_install":            true,
+	})
+
+	chdir(t, childDir)
+	useConfig(t, tvmConfig{
+		BaseURL:              "https://releases.hashicorp.com/terraform/",
+		IndexCacheTTLMinutes: 60,
+	})
+
+	if err := loadProjectConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.IndexCacheTTLMinutes != 10 {
+		t.Errorf("index_cache_ttl_minutes = %d, want 10 from the nearest .tvmrc", cfg.IndexCacheTTLMinutes)
+	}
+
+	if cfg.Quiet {
+		t.Error("quiet = true, want false from the nearest .tvmrc")
+	}
+
+	if !cfg.IncludePrerelease {
+		t.Error("include_prerelease = false, want true from the parent .tvmrc")
+	}
+
+	if cfg.BaseURL != "https://releases.hashicorp.com/terraform/" {
+		t.Errorf("base_url = %q, want it left alone", cfg.BaseURL)
+	}
+
+	if cfg.AutoInstall {
+		t.Error("auto_install = true, want it left alone")
+	}
+}
+
+func TestLoadProjectConfigIgnoresGlobalOnlyKeys(t *testing.T) {
+	project := tvmConfig{
+		BaseURL:               "https://mirror.example.com/terraform/",
+		AutoInstall:           true,
+		InsecureSkipTLSVerify: true,
+		PostInstall:           "touch /tmp/pwned",
+		AllowedVersions:       []string{">= 0.1"},
+		DeniedVersions:        []string{"< 99"},
+		BlockDeniedExec:       true,
+		Advisories:            false,
+		SystemFallback:        true,
+		StrictState:           true,
+		RetryOnLock:           5,
+		RetryLockCommands:     []string{"apply"},
+		GCAuto:                true,
+		GCIndexMaxAgeHours:    1,
+		GCArchiveMaxAgeDays:   1,
+		GCKeepVersions:        1,
+		GCVersionMaxAgeDays:   1,
+		AuditLog:              true,
+		AuditLogPath:          "/tmp/audit.log",
+		AuditLogFormat:        "text",
+		TrustedProjectDirs:    []string{"/"},
+	}
+
+	data, err := json.Marshal(project)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]interface{}{}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		t.Fatal(err)
+	}
+
+	global := tvmConfig{
+		BaseURL:           "https://releases.hashicorp.com/terraform/",
+		Advisories:        true,
+		RetryLockCommands: []string{"plan"},
+	}
+
+	// The keys are listed again rather than taken from globalOnlyKeys, so
+	// that removing one from there fails the test.
+	keys := []string{
+		"base_url", "auto_install", "insecure_skip_tls_verify", "post_install",
+		"allowed_versions", "denied_versions", "block_denied_exec", "advisories",
+		"system_fallback", "strict_state", "retry_on_lock", "retry_lock_commands",
+		"gc_auto", "gc_index_max_age_hours", "gc_archive_max_age_days", "gc_keep_versions",
+		"gc_version_max_age_days",
+		"audit_log", "audit_log_path", "audit_log_format", "trusted_project_dirs",
+	}
+
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			value, ok := values[key]
+
+			if !ok {
+				t.Fatalf("no test value for %s", key)
+			}
+
+			dir := t.TempDir()
+			writeProjectConfig(t, dir, map[string]interface{}{key: value})
+
+			chdir(t, dir)
+			useConfig(t, global)
+
+			if err := loadProjectConfig(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(cfg, global) {
+				t.Errorf("%s = %v in .tvmrc changed the configuration to %+v", key, value, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadProjectConfigTrustedProjectDirs(t *testing.T) {
+	trustedDir := t.TempDir()
+	projectDir := filepath.Join(trustedDir, "project")
+
+	if err := os.Mkdir(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]interface{}{
+		"base_url":     "https://mirror.example.com/terraform/",
+		"auto_install": true,
+		"post_install": "touch /tmp/pwned",
+	}
+
+	global := tvmConfig{
+		BaseURL:            "https://releases.hashicorp.com/terraform/",
+		TrustedProjectDirs: []string{trustedDir},
+	}
+
+	t.Run("trusted", func(t *testing.T) {
+		writeProjectConfig(t, projectDir, values)
+
+		chdir(t, projectDir)
+		useConfig(t, global)
+
+		if err := loadProjectConfig(); err != nil {
+			t.Fatal(err)
+		}
+
+		if cfg.BaseURL != "https://mirror.example.com/terraform/" {
+			t.Errorf("base_url = %q, want it from the .tvmrc of the trusted directory", cfg.BaseURL)
+		}
+
+		if !cfg.AutoInstall {
+			t.Error("auto_install = false, want it from the .tvmrc of the trusted directory")
+		}
+
+		if cfg.PostInstall != "" {
+			t.Errorf("post_install = %q, want it left alone", cfg.PostInstall)
+		}
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		// A sibling sharing the prefix of the trusted directory isn't below it.
+		untrustedDir := trustedDir + "-untrusted"
+
+		if err := os.Mkdir(untrustedDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() {
+			if err := os.RemoveAll(untrustedDir); err != nil {
+				t.Error(err)
+			}
+		})
+
+		writeProjectConfig(t, untrustedDir, values)
+
+		chdir(t, untrustedDir)
+		useConfig(t, global)
+
+		if err := loadProjectConfig(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(cfg, global) {
+			t.Errorf(".tvmrc outside of the trusted directories changed the configuration to %+v", cfg)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/go-version"
+)
+
+// changelogURL is where the changes of Terraform versions are published.
+const changelogURL = "https://github.com/hashicorp/terraform"
+
+type diffOptions struct {
+	Prerelease bool
+}
+
+// diff prints the versions released after from up to to, with links to
+// their release notes and to the changes of the whole range.
+func diff(from *version.Version, to *version.Version, opts diffOptions) {
+	if from.GreaterThan(to) {
+		from, to = to, from
+	}
+
+	releases, err := newManager().ListRemote(context.Background())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	versions := make([]*version.Version, 0)
+
+	for _, release := range releases {
+		if release.Version.GreaterThan(from) && !release.Version.GreaterThan(to) {
+			versions = append(versions, release.Version)
+		}
+	}
+
+	versions = selectVersions(versions, listOptions{Prerelease: opts.Prerelease})
+
+	if len(versions) == 0 {
+		fmt.Printf("No version released after %s up to %s\n", from, to)
+
+		return
+	}
+
+	for _, v := range versions {
+		fmt.Printf("%-12s %s/releases/tag/v%s\n", v, changelogURL, v)
+	}
+
+	fmt.Printf("\nChanges from %s to %s: %s/compare/v%s...v%s\n", from, to, changelogURL, from, to)
+}
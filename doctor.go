@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+type doctorOptions struct {
+	Mirror bool
+}
+
+// doctor reports the problems found with the setup of tvm and returns
+// whether there were none.
+func doctor(opts doctorOptions) bool {
+	if !opts.Mirror {
+		fmt.Println("Nothing to check, use --mirror to check the releases index")
+
+		return true
+	}
+
+	ok := true
+
+	for _, check := range newManager().CheckMirror(context.Background()) {
+		if check.Err != nil {
+			fmt.Printf("%-4s %-12s %s: %s\n", "FAIL", check.Name, check.URL, check.Err)
+			ok = false
+		} else {
+			fmt.Printf("%-4s %-12s %s\n", "OK", check.Name, check.URL)
+		}
+	}
+
+	return ok
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// systemFallbackEnv is set when running the system Terraform, so that a
+// system terraform which is in fact a wrapper calling back into tvm fails
+// instead of looping.
+const systemFallbackEnv = "TVM_SYSTEM_FALLBACK_ACTIVE"
+
+// findSystemTerraform returns the first terraform in PATH which is not tvm
+// itself, as it is when tvm is used as a terraform shim.
+func findSystemTerraform() (string, error) {
+	self, err := os.Executable()
+
+	if err != nil {
+		return "", err
+	}
+
+	selfInfo, err := os.Stat(self)
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+
+		candidate := filepath.Join(dir, "terraform")
+
+		info, err := os.Stat(candidate)
+
+		if err != nil || info.IsDir() || info.Mode()&0111 == 0 || os.SameFile(info, selfInfo) {
+			continue
+		}
+
+		return candidate, nil
+	}
+
+	return "", fmt.Errorf("No system terraform found in PATH")
+}
+
+// execSystemTerraform replaces tvm with the system Terraform, when no
+// installed version matches the constraints.
+func execSystemTerraform(args []string) {
+	if os.Getenv(systemFallbackEnv) != "" {
+		fmt.Println("The system terraform called tvm back, not falling back again")
+		os.Exit(1)
+	}
+
+	binPath, err := findSystemTerraform()
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	warnf("No installed version matched the constraints, falling back to %s", binPath)
+
+	env := append(os.Environ(), systemFallbackEnv+"=1")
+
+	if err := syscall.Exec(binPath, append([]string{"terraform"}, args...), env); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+type gcOptions struct {
+	DryRun bool
+	// Quiet is set when gc runs at the end of another command, whose output
+	// the report must not be mixed with: it then goes to stderr and nothing
+	// is printed when nothing was collected.
+	Quiet bool
+}
+
+// staleCacheNames are the files earlier releases of tvm kept in the cache
+// directory and which are no longer used.
+var staleCacheNames = map[string]bool{
+	"installed.json": true,
+}
+
+// gc applies the retention policy from the configuration: index cache files
+// and cached archives older than their maximum age are removed, and so are
+// the installed versions installed longer than gc_version_max_age_days ago,
+// except for the newest gc_keep_versions ones which are always kept. A zero
+// maximum age or gc_keep_versions disables the corresponding collection.
+// Files of the cache directory which tvm doesn't know are left alone, but for
+// the stale ones of earlier releases.
+func gc(opts gcOptions) {
+	now := time.Now()
+	collected := 0
+	var out io.Writer = os.Stdout
+
+	if opts.Quiet {
+		out = os.Stderr
+	}
+
+	collect := func(what string, path string) {
+		if opts.DryRun {
+			fmt.Fprintf(out, "Would remove %s %s\n", what, path)
+		} else if err := os.RemoveAll(path); err != nil {
+			warnf("Error removing %s %s: %s", what, path, err)
+
+			return
+		} else {
+			fmt.Fprintf(out, "Removed %s %s\n", what, path)
+		}
+
+		collected++
+	}
+
+	cacheEntries, err := ioutil.ReadDir(cacheDirPath)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cacheEntry := range cacheEntries {
+		name := cacheEntry.Name()
+
+		if staleCacheNames[name] {
+			collect("stale cache", path.Join(cacheDirPath, name))
+
+			continue
+		}
+
+		var what string
+		var maxAge time.Duration
+
+		// Lock files are never matched: removing one would let another
+		// process take the lock while it is held.
+		switch {
+		case isIndexCacheName(name):
+			what = "index cache"
+			maxAge = time.Duration(cfg.GCIndexMaxAgeHours) * time.Hour
+		case strings.HasSuffix(name, ".zip"):
+			what = "cached archive"
+			maxAge = time.Duration(cfg.GCArchiveMaxAgeDays) * 24 * time.Hour
+		default:
+			continue
+		}
+
+		if maxAge <= 0 || now.Sub(cacheEntry.ModTime()) < maxAge {
+			continue
+		}
+
+		collect(what, path.Join(cacheDirPath, name))
+	}
+
+	versionMaxAge := time.Duration(cfg.GCVersionMaxAgeDays) * 24 * time.Hour
+
+	if cfg.GCKeepVersions > 0 && versionMaxAge > 0 {
+		m := newManager()
+
+		versions, err := m.ListInstalled()
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for i := len(versions) - cfg.GCKeepVersions - 1; i >= 0; i-- {
+			if now.Sub(installedAt(m, versions[i])) < versionMaxAge {
+				continue
+			}
+
+			collect("Terraform version", m.VersionDir(versions[i]))
+		}
+	}
+
+	if collected == 0 && !opts.Quiet {
+		fmt.Fprintf(out, "Nothing to collect\n")
+	}
+}
+
+// isIndexCacheName reports whether name is the index cache, index.json, or
+// one of the temporary files it is written to, index followed by digits,
+// which an interrupted run may leave behind.
+func isIndexCacheName(name string) bool {
+	if name == "index.json" {
+		return true
+	}
+
+	suffix := strings.TrimPrefix(name, "index")
+
+	if suffix == name || suffix == "" {
+		return false
+	}
+
+	for _, c := range suffix {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// installedAt returns when v was installed according to its metadata or, for
+// versions installed without, the modification time of its directory.
+func installedAt(m *tvm.Manager, v *version.Version) time.Time {
+	if metadata, err := m.Metadata(v); err == nil && !metadata.InstalledAt.IsZero() {
+		return metadata.InstalledAt
+	}
+
+	info, err := os.Stat(m.VersionDir(v))
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
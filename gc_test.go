@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGCKeepsNewestAndRecentVersions(t *testing.T) {
+	previousDataDirPath, previousCacheDirPath := dataDirPath, cacheDirPath
+	dataDirPath, cacheDirPath = t.TempDir(), t.TempDir()
+
+	t.Cleanup(func() {
+		dataDirPath, cacheDirPath = previousDataDirPath, previousCacheDirPath
+	})
+
+	useConfig(t, tvmConfig{GCKeepVersions: 2, GCVersionMaxAgeDays: 30})
+
+	ages := map[string]time.Duration{
+		"1.0.0": 60 * 24 * time.Hour,
+		"1.1.0": 24 * time.Hour,
+		"1.2.0": 60 * 24 * time.Hour,
+		"1.3.0": 60 * 24 * time.Hour,
+	}
+
+	for v, age := range ages {
+		versionDirPath := filepath.Join(dataDirPath, "versions", v)
+
+		if err := os.MkdirAll(versionDirPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := json.Marshal(map[string]interface{}{"version": v, "installed_at": time.Now().Add(-age)})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(versionDirPath, "metadata.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gc(gcOptions{Quiet: true})
+
+	// 1.3.0 and 1.2.0 are the newest two, 1.1.0 was installed recently.
+	for v, wantKept := range map[string]bool{"1.0.0": false, "1.1.0": true, "1.2.0": true, "1.3.0": true} {
+		_, err := os.Stat(filepath.Join(dataDirPath, "versions", v))
+
+		if kept := err == nil; kept != wantKept {
+			t.Errorf("%s kept: %t, want %t", v, kept, wantKept)
+		}
+	}
+}
+
+func TestGCCacheEntries(t *testing.T) {
+	previousDataDirPath, previousCacheDirPath := dataDirPath, cacheDirPath
+	dataDirPath, cacheDirPath = t.TempDir(), t.TempDir()
+
+	t.Cleanup(func() {
+		dataDirPath, cacheDirPath = previousDataDirPath, previousCacheDirPath
+	})
+
+	useConfig(t, tvmConfig{GCIndexMaxAgeHours: 1, GCArchiveMaxAgeDays: 1})
+
+	old := time.Now().Add(-48 * time.Hour)
+	wantKept := map[string]bool{
+		"index.json":                      false,
+		"index123456":                     false,
+		"terraform_1.5.7_linux_amd64.zip": false,
+		"index.json.lock":                 true,
+		"indexes":                         true,
+		"notes.txt":                       true,
+		"installed.json":                  false,
+	}
+
+	for name := range wantKept {
+		filePath := filepath.Join(cacheDirPath, name)
+
+		if err := ioutil.WriteFile(filePath, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(filePath, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdoutReader, stdoutWriter, err := os.Pipe()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previousStdout := os.Stdout
+	os.Stdout = stdoutWriter
+
+	gc(gcOptions{Quiet: true})
+
+	os.Stdout = previousStdout
+
+	if err := stdoutWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, err := ioutil.ReadAll(stdoutReader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stdout) != 0 {
+		t.Errorf("gc printed %q on stdout, want its report on stderr", stdout)
+	}
+
+	for name, wantKept := range wantKept {
+		_, err := os.Stat(filepath.Join(cacheDirPath, name))
+
+		if kept := err == nil; kept != wantKept {
+			t.Errorf("%s kept: %t, want %t", name, kept, wantKept)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+// constraintsDir returns the directory to read the constraints from: the
+// current directory or, with a git ref, a copy of it as of that ref. The
+// returned function removes the copy.
+func constraintsDir(gitRef string) (string, func(), error) {
+	if gitRef == "" {
+		return workingDir(), func() {}, nil
+	}
+
+	return gitRefDir(gitRef)
+}
+
+// gitRefDir extracts the Terraform configuration files and the
+// .terraform-version files of the git repository the current directory is
+// in, as of ref, to a temporary directory. It returns the copy of the
+// current directory, whose parents are copied too for the local modules.
+func gitRefDir(ref string) (string, func(), error) {
+	topLevel, err := git("rev-parse", "--show-toplevel")
+
+	if err != nil {
+		return "", nil, fmt.Errorf("--git-ref can only be used inside a git repository")
+	}
+
+	prefix, err := git("rev-parse", "--show-prefix")
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if _, err := git("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return "", nil, fmt.Errorf("Unknown git ref %q", ref)
+	}
+
+	tmpDirPath, err := ioutil.TempDir("", "tvm-git-ref")
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	cleanup := func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil {
+			warnf("Error removing %s", tmpDirPath)
+		}
+	}
+
+	if err := extractGitTree(strings.TrimSpace(topLevel), ref, tmpDirPath); err != nil {
+		cleanup()
+
+		return "", nil, fmt.Errorf("Failed to read the configuration at %s: %s", ref, err)
+	}
+
+	dir := filepath.Join(tmpDirPath, filepath.FromSlash(strings.TrimSpace(prefix)))
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		cleanup()
+
+		return "", nil, err
+	}
+
+	return dir, cleanup, nil
+}
+
+// extractGitTree writes the files of the tree of ref, in the repository
+// whose top level directory is topLevel, which tvm reads the constraints
+// from beneath dst.
+func extractGitTree(topLevel string, ref string, dst string) error {
+	cmd := osexec.Command("git", "archive", "--format=tar", ref)
+	cmd.Dir = topLevel
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := extractConstraintsFiles(tar.NewReader(stdout), dst); err != nil {
+		if err := cmd.Process.Kill(); err != nil {
+			warnf("Error killing git archive")
+		}
+
+		_ = cmd.Wait()
+
+		return err
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+func extractConstraintsFiles(tarReader *tar.Reader, dst string) error {
+	for {
+		header, err := tarReader.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg || !isConstraintsFile(header.Name) {
+			continue
+		}
+
+		dstPath := filepath.Join(dst, filepath.FromSlash(header.Name))
+
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadAll(tarReader)
+
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(dstPath, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isConstraintsFile(name string) bool {
+	base := filepath.Base(name)
+
+	return base == tvm.PinFileName || strings.HasSuffix(base, ".tf") || strings.HasSuffix(base, ".tf.json")
+}
+
+// git runs git in the current directory and returns its output.
+func git(args ...string) (string, error) {
+	cmd := osexec.Command("git", args...)
+	cmd.Dir = workingDir()
+
+	output, err := cmd.Output()
+
+	return string(output), err
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"runtime"
+
+	"github.com/hashicorp/go-version"
+)
+
+// runPostInstallHook runs the configured post-install command through the
+// shell. The installed version and binary path are passed both as positional
+// arguments ($1 and $2) and as TVM_VERSION and TVM_BINARY_PATH.
+func runPostInstallHook(hook string, version *version.Version, binPath string) error {
+	var cmd *osexec.Cmd
+
+	if runtime.GOOS == "windows" {
+		cmd = osexec.Command("cmd", "/C", hook, version.String(), binPath)
+	} else {
+		cmd = osexec.Command("sh", "-c", hook, "tvm", version.String(), binPath)
+	}
+
+	// The hook doesn't get the standard input, which belongs to Terraform
+	// when it is installed by exec, and reads from the null device instead.
+	cmd.Stdin = nil
+
+	// Its output goes where the information of tvm does, stderr when exec
+	// installs a version, not to be mixed with the output of Terraform.
+	cmd.Stdout = infoOutput
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"TVM_VERSION="+version.String(),
+		"TVM_BINARY_PATH="+binPath,
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Post-install hook failed: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+func TestRunPostInstallHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook runs through cmd on Windows")
+	}
+
+	dir := t.TempDir()
+	argsPath := filepath.Join(dir, "args")
+	stdinPath := filepath.Join(dir, "stdin")
+
+	stdinReader, stdinWriter, err := os.Pipe()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stdinWriter.Write([]byte("meant for terraform\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stdinWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	previousStdin := os.Stdin
+	os.Stdin = stdinReader
+
+	t.Cleanup(func() {
+		os.Stdin = previousStdin
+
+		if err := stdinReader.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	hook := `echo "$1 $2 $TVM_VERSION $TVM_BINARY_PATH" > ` + argsPath + ` && cat > ` + stdinPath
+
+	if err := runPostInstallHook(hook, version.Must(version.NewVersion("1.5.7")), "/opt/terraform"); err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := ioutil.ReadFile(argsPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "1.5.7 /opt/terraform 1.5.7 /opt/terraform\n"; string(args) != want {
+		t.Errorf("hook got %q, want %q", args, want)
+	}
+
+	stdin, err := ioutil.ReadFile(stdinPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stdin) != 0 {
+		t.Errorf("hook read %q from the standard input of tvm", stdin)
+	}
+}
+
+func TestRunPostInstallHookFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook runs through cmd on Windows")
+	}
+
+	if err := runPostInstallHook("exit 3", version.Must(version.NewVersion("1.5.7")), "/opt/terraform"); err == nil {
+		t.Error("failing hook succeeded")
+	}
+}
+
+func TestRunPostInstallHookOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook runs through cmd on Windows")
+	}
+
+	output := &bytes.Buffer{}
+	previousInfoOutput := infoOutput
+	infoOutput = output
+
+	t.Cleanup(func() {
+		infoOutput = previousInfoOutput
+	})
+
+	if err := runPostInstallHook("echo registered", version.Must(version.NewVersion("1.5.7")), "/opt/terraform"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := output.String(); got != "registered\n" {
+		t.Errorf("hook output = %q, want it where tvm prints information", got)
+	}
+}
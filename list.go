@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/template"
+
+	"github.com/hashicorp/go-version"
+)
+
+type listOptions struct {
+	Remote     bool
+	Installed  bool
+	Verify     bool
+	JSON       bool
+	Desc       bool
+	Limit      int
+	Prerelease bool
+	Since      *version.Version
+	Format     *template.Template
+	Page       int
+	PerPage    int
+}
+
+// selectVersions filters out pre-releases unless asked for and versions not
+// newer than Since, sorts and truncates versions according to the list
+// options.
+func selectVersions(versions []*version.Version, opts listOptions) []*version.Version {
+	selectedVersions := make([]*version.Version, 0, len(versions))
+
+	for _, v := range versions {
+		if !opts.Prerelease && v.Prerelease() != "" {
+			continue
+		}
+
+		if opts.Since != nil && !v.GreaterThan(opts.Since) {
+			continue
+		}
+
+		selectedVersions = append(selectedVersions, v)
+	}
+
+	versions = selectedVersions
+
+	if opts.Desc {
+		sort.Sort(sort.Reverse(version.Collection(versions)))
+	} else {
+		sort.Sort(version.Collection(versions))
+	}
+
+	if opts.Limit > 0 && len(versions) > opts.Limit {
+		versions = versions[:opts.Limit]
+	}
+
+	return versions
+}
+
+// paginate returns the Page'th page of PerPage versions, counting from 1,
+// and its description, or versions and nil when PerPage is not set. A page
+// past the last one is empty.
+func paginate(versions []*version.Version, opts listOptions) ([]*version.Version, *listPage) {
+	if opts.PerPage <= 0 {
+		return versions, nil
+	}
+
+	page := &listPage{
+		Page:    opts.Page,
+		PerPage: opts.PerPage,
+		Total:   len(versions),
+		Pages:   (len(versions) + opts.PerPage - 1) / opts.PerPage,
+	}
+
+	start := (opts.Page - 1) * opts.PerPage
+
+	if start >= len(versions) {
+		return versions[:0], page
+	}
+
+	end := start + opts.PerPage
+
+	if end > len(versions) {
+		end = len(versions)
+	}
+
+	return versions[start:end], page
+}
+
+func list(opts listOptions) {
+	if opts.Installed {
+		listInstalled(opts)
+
+		return
+	}
+
+	releases, err := newManager().ListRemote(context.Background())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	versions := make([]*version.Version, len(releases))
+
+	for i, release := range releases {
+		versions[i] = release.Version
+	}
+
+	versions, page := paginate(selectVersions(versions, opts), opts)
+
+	if opts.Format != nil {
+		m := newManager()
+		entries := make([]listEntry, len(versions))
+
+		for i, v := range versions {
+			entries[i] = listEntry{Version: v.String()}
+
+			// Only the presence of the binary is checked, IsInstalled would
+			// hash it against its metadata for every listed version.
+			if _, err := os.Stat(m.BinaryPath(v)); err == nil {
+				entries[i].Installed = true
+				entries[i].Path = m.BinaryPath(v)
+			}
+		}
+
+		printFormatted(opts.Format, entries)
+
+		return
+	}
+
+	if opts.JSON {
+		entries := make([]listEntry, len(versions))
+
+		for i, v := range versions {
+			entries[i] = listEntry{Version: v.String()}
+		}
+
+		printJSONList(entries, page)
+
+		return
+	}
+
+	for _, v := range versions {
+		fmt.Println(v)
+	}
+}
+
+// listInstalled lists the installed versions, pre-releases included as they
+// were installed on purpose.
+func listInstalled(opts listOptions) {
+	m := newManager()
+	opts.Prerelease = true
+
+	versions, err := m.ListInstalled()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	versions, page := paginate(selectVersions(versions, opts), opts)
+
+	entries := make([]listEntry, len(versions))
+
+	for i, v := range versions {
+		entries[i] = listEntry{
+			Version:   v.String(),
+			Path:      m.BinaryPath(v),
+			Installed: true,
+		}
+
+		if opts.Verify {
+			entries[i].Status = m.Verify(v)
+		}
+	}
+
+	if opts.Format != nil {
+		printFormatted(opts.Format, entries)
+
+		return
+	}
+
+	if opts.JSON {
+		printJSONList(entries, page)
+
+		return
+	}
+
+	for _, entry := range entries {
+		if opts.Verify {
+			fmt.Printf("%-10s %-8s %s\n", entry.Version, entry.Status, entry.Path)
+		} else {
+			fmt.Println(entry.Version)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// TestOutputIsLocaleIndependent runs TestLocaleHelperProcess under
+// environments with exotic locales and time zones, which only take effect in
+// a new process, and expects the same output from all of them.
+func TestOutputIsLocaleIndependent(t *testing.T) {
+	envs := [][]string{
+		{"LC_ALL=C", "LANG=C", "TZ=UTC"},
+		{"LC_ALL=tr_TR.UTF-8", "LANG=tr_TR.UTF-8", "TZ=Asia/Kolkata"},
+		{"LC_ALL=ar_EG.UTF-8", "LANG=ar_EG.UTF-8", "TZ=America/St_Johns"},
+		{"LC_ALL=", "LANG=fa_IR.UTF-8", "LC_NUMERIC=hi_IN.UTF-8", "TZ=Pacific/Chatham"},
+	}
+
+	var want string
+
+	for i, env := range envs {
+		cmd := osexec.Command(os.Args[0], "-test.run=^TestLocaleHelperProcess$")
+		cmd.Env = append(append(os.Environ(), "TVM_TEST_LOCALE_HELPER=1"), env...)
+
+		output, err := cmd.CombinedOutput()
+
+		if err != nil {
+			t.Fatalf("%v: %s: %s", env, err, output)
+		}
+
+		if i == 0 {
+			want = string(output)
+
+			continue
+		}
+
+		if string(output) != want {
+			t.Errorf("output with %v:\n%s\nwant:\n%s", env, output, want)
+		}
+	}
+}
+
+// TestLocaleHelperProcess prints versions parsed, sorted and formatted as the
+// list command does, and the exec message at a fixed instant.
+func TestLocaleHelperProcess(t *testing.T) {
+	if os.Getenv("TVM_TEST_LOCALE_HELPER") != "1" {
+		t.Skip("only run by TestOutputIsLocaleIndependent")
+	}
+
+	rawVersions := []string{"1.10.0", "1.9.2", "0.11.14", "1.6.0-beta1", "1.6.0", "v1.2.3", "1.6.0-RC1", "1.6.0-rc.1"}
+	versions := make([]*version.Version, 0, len(rawVersions))
+
+	for _, raw := range rawVersions {
+		v, err := version.NewVersion(raw)
+
+		if err != nil {
+			fmt.Printf("%s: %s\n", raw, err)
+
+			continue
+		}
+
+		versions = append(versions, v)
+	}
+
+	for _, opts := range []listOptions{{Prerelease: true}, {Desc: true}} {
+		entries := make([]listEntry, 0, len(versions))
+
+		for _, v := range selectVersions(versions, opts) {
+			entries = append(entries, listEntry{Version: v.String()})
+		}
+
+		printJSONList(entries, nil)
+	}
+
+	fmt.Print(runningMessage(versions[0], "required_version", time.Unix(1791993600, 0)))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-version"
+)
+
+// dependencyLockFileName is the dependency lock file of Terraform, which
+// records the provider hashes and has nothing to do with the versions tvm
+// pins.
+const dependencyLockFileName = ".terraform.lock.hcl"
+
+// providersLockConstraint matches the versions which have the providers lock
+// command.
+var providersLockConstraint = version.MustConstraints(version.NewConstraint(">= 0.14"))
+
+// lockDependencies runs terraform providers lock in dir with the Terraform
+// binary of tfVersion, so that the dependency lock file has the hashes of the
+// providers for every one of the platforms.
+func lockDependencies(dir string, tfVersion *version.Version, binPath string, platforms []string) error {
+	if !providersLockConstraint.Check(tfVersion) {
+		warnf("Not locking provider dependencies, Terraform %s has no providers lock command", tfVersion)
+
+		return nil
+	}
+
+	args := []string{"providers", "lock"}
+
+	for _, platform := range platforms {
+		args = append(args, "-platform="+platform)
+	}
+
+	exitCode, err := runTerraform(binPath, args, os.Environ(), os.Stderr, 0)
+
+	if err != nil {
+		return err
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("terraform providers lock exited with status %d", exitCode)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, dependencyLockFileName)); os.IsNotExist(err) {
+		fmt.Println("No provider to lock")
+	}
+
+	return nil
+}
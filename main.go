@@ -1,32 +1,54 @@
 package main
 
 import (
-	"archive/zip"
-	"bytes"
-	"crypto/sha256"
+	"context"
 	"flag"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"net/url"
 	"os"
+	osexec "os/exec"
 	"path"
+	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
 	"syscall"
+	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/hashicorp/go-version"
-	"github.com/hashicorp/terraform/config"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
 )
 
-type tfVersion struct {
-	Version              *version.Version
-	URL                  *url.URL
-	ChecksumURL          *url.URL
-	ChecksumSignatureURL *url.URL
+type installOptions struct {
+	StrictHooks    bool
+	Force          bool
+	DependencyLock bool
+	LockPlatforms  []string
+	MetricsFile    string
+	FromBundle     string
+	Dir            string
+	RefreshOnly    bool
+	ChecksumOnly   bool
+	BinaryName     string
+	OutputDir      string
+	WaitForRelease bool
+	WaitTimeout    time.Duration
+	PollInterval   time.Duration
+}
+
+type execOptions struct {
+	GitRef            string
+	StateCheck        bool
+	StrictState       bool
+	CPUProfile        string
+	Trace             string
+	Quiet             bool
+	WithPath          bool
+	SystemFallback    bool
+	Timeout           time.Duration
+	RetryOnLock       int
+	RetryLockCommands []string
+	RetryLockBackoff  time.Duration
 }
 
 var (
@@ -34,17 +56,18 @@ var (
 	dataDirPath       string
 	tfVersionsDirPath string
 	cacheDirPath      string
-)
+	allowInsecure     bool
 
-func init() {
-	_baseURL, err := url.Parse("https://releases.hashicorp.com/terraform/")
+	insecureSkipVerify bool
 
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	baseURL = _baseURL
+	recursiveConstraints bool
+	verbose              bool
+)
 
+// setup creates the data and cache directories and loads the configuration.
+// It is called by main rather than being an init function, so that the tests
+// of the package leave the directories of the user alone.
+func setup() {
 	userHomeDirPath, err := os.UserHomeDir()
 
 	if err != nil {
@@ -86,15 +109,162 @@ func init() {
 			log.Fatal(err)
 		}
 	}
+
+	if err := loadConfig(); err != nil {
+		log.Fatal(err)
+	}
+
+	_baseURL, err := tvm.ParseBaseURL(cfg.BaseURL)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	baseURL = _baseURL
+	insecureSkipVerify = cfg.InsecureSkipTLSVerify
 }
 
 func main() {
+	setup()
+
 	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
 	installCmd := flag.NewFlagSet("install", flag.ExitOnError)
 	execCmd := flag.NewFlagSet("exec", flag.ExitOnError)
+	gcCmd := flag.NewFlagSet("gc", flag.ExitOnError)
+	whichCmd := flag.NewFlagSet("which", flag.ExitOnError)
+	selectCmd := flag.NewFlagSet("select", flag.ExitOnError)
+	pruneFailedCmd := flag.NewFlagSet("prune-failed", flag.ExitOnError)
+	bundleCmd := flag.NewFlagSet("bundle", flag.ExitOnError)
+	doctorCmd := flag.NewFlagSet("doctor", flag.ExitOnError)
+	diffCmd := flag.NewFlagSet("diff", flag.ExitOnError)
+	reinstallCmd := flag.NewFlagSet("reinstall", flag.ExitOnError)
+	runCmd := flag.NewFlagSet("run", flag.ExitOnError)
+	installedPathCmd := flag.NewFlagSet("installed-path", flag.ExitOnError)
+	statusCmd := flag.NewFlagSet("status", flag.ExitOnError)
+
+	commands := []*flag.FlagSet{listCmd, installCmd, reinstallCmd, execCmd, runCmd, whichCmd, installedPathCmd, selectCmd, diffCmd, bundleCmd, gcCmd, pruneFailedCmd, statusCmd, doctorCmd}
+
+	listOpts := listOptions{}
+	listCmd.BoolVar(&listOpts.Remote, "remote", false, "List available versions (the default)")
+	listCmd.BoolVar(&listOpts.Installed, "installed", false, "List installed versions instead of available ones")
+	listCmd.BoolVar(&listOpts.Desc, "desc", false, "List newest versions first")
+	listCmd.IntVar(&listOpts.Limit, "limit", 0, "List at most this many versions (0 lists all of them)")
+	listCmd.BoolVar(&listOpts.Prerelease, "prerelease", cfg.IncludePrerelease, "Include pre-release versions in the remote listing, the installed ones are always listed")
+	listCmd.BoolVar(&verbose, "verbose", false, "Print details about what tvm is doing")
+	listCmd.BoolVar(&listOpts.Verify, "verify", false, "Verify installed binaries against the metadata recorded at install time (with --installed)")
+	listCmd.BoolVar(&listOpts.JSON, "json", false, "Output JSON")
+	listCmd.BoolVar(&compactJSON, "compact", false, "Output single-line JSON (with --json)")
+	listCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	listCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+	listCmd.IntVar(&listOpts.Page, "page", 1, "Page of versions to list, counting from 1 (with --per-page)")
+	listCmd.IntVar(&listOpts.PerPage, "per-page", 0, "List the versions by pages of this many (0 lists all of them)")
+	listSince := listCmd.String("since", "", "Only list versions newer than this one")
+	listFormat := listCmd.String("format", "", "Print each version with this Go template, over the fields .Version, .Installed, .Path and, with --verify, .Status")
+
+	installOpts := installOptions{
+		LockPlatforms: []string{runtime.GOOS + "_" + runtime.GOARCH},
+	}
+	installCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	installCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+	installCmd.BoolVar(&recursiveConstraints, "recursive-constraints", cfg.RecursiveConstraints, "Also honor required_version of local child modules")
+	installCmd.BoolVar(&verbose, "verbose", false, "Print details about what tvm is doing")
+	installCmd.BoolVar(&installOpts.Force, "force", false, "Download and install even if the version is already installed")
+	installCmd.BoolVar(&installOpts.StrictHooks, "strict-hooks", cfg.StrictHooks, "Fail the install if the post-install hook fails")
+	installCmd.BoolVar(&installOpts.DependencyLock, "dependency-lock", false, "Run terraform providers lock with the installed version to fill in .terraform.lock.hcl")
+	installCmd.StringVar(&installOpts.FromBundle, "from-bundle", "", "Install the versions of a bundle written by tvm bundle instead of downloading")
+	installCmd.StringVar(&installOpts.MetricsFile, "metrics", "", "Write the time spent in each phase of the install to this file, in OpenMetrics text format")
+	installCmd.StringVar(&installOpts.BinaryName, "binary-name", "", "Extract the binary under this name, such as terraform-1.6.6, to the --output-dir directory instead of installing it")
+	installCmd.StringVar(&installOpts.OutputDir, "output-dir", ".", "Directory to extract the binary to, with --binary-name")
+	installCmd.BoolVar(&installOpts.ChecksumOnly, "checksum-only", false, "Only download and verify the archive of the version, installing nothing")
+	installCmd.BoolVar(&installOpts.RefreshOnly, "refresh-only", false, "Only refresh the cached list of available versions, installing nothing")
+	installCmd.BoolVar(&installOpts.WaitForRelease, "wait-for-release", false, "When the exact version required isn't available yet, poll the releases index until it is")
+	installCmd.DurationVar(&installOpts.WaitTimeout, "timeout", 30*time.Minute, "How long to wait for the release, with --wait-for-release")
+	installCmd.DurationVar(&installOpts.PollInterval, "poll-interval", time.Minute, "Delay between polls of the releases index, with --wait-for-release (at least 10s)")
+	installCmd.Var(commaSeparatedValue{&installOpts.LockPlatforms}, "lock-platforms", "Comma separated list of the platforms to lock the provider hashes of, with --dependency-lock")
+
+	execOpts := execOptions{
+		Quiet:             cfg.Quiet,
+		StateCheck:        cfg.StateCheck,
+		StrictState:       cfg.StrictState,
+		SystemFallback:    cfg.SystemFallback,
+		RetryLockCommands: cfg.RetryLockCommands,
+	}
+	execCmd.StringVar(&execOpts.GitRef, "git-ref", "", "Run the version required by the configuration as of this git ref rather than as checked out")
+	execCmd.BoolVar(&execOpts.StateCheck, "state-check", cfg.StateCheck, "Warn when the local state was written by another release line of Terraform than the one run")
+	execCmd.BoolVar(&execOpts.StrictState, "strict-state", cfg.StrictState, "Refuse to run Terraform when the state check fails (implies --state-check)")
+	execCmd.BoolVar(&execOpts.Quiet, "quiet", cfg.Quiet, "Don't report which Terraform version is run")
+	execCmd.BoolVar(&execOpts.SystemFallback, "system-fallback", cfg.SystemFallback, "Run the terraform found in PATH when no installed version matches the constraints")
+	execCmd.DurationVar(&execOpts.Timeout, "timeout", 0, "Stop Terraform, exiting with status 124, if it runs longer than this (Terraform then runs as a child of tvm instead of replacing it)")
+	execCmd.StringVar(&execOpts.CPUProfile, "cpu-profile", "", "Write a CPU profile of tvm, up to running Terraform, to this file")
+	execCmd.StringVar(&execOpts.Trace, "trace", "", "Write an execution trace of tvm, up to running Terraform, to this file")
+	execCmd.BoolVar(&execOpts.WithPath, "with-path", false, "Run the given command, such as a Terraform wrapper, with the directory of the Terraform binary first in PATH instead of running Terraform")
+	execCmd.BoolVar(&recursiveConstraints, "recursive-constraints", cfg.RecursiveConstraints, "Also honor required_version of local child modules")
+	execCmd.IntVar(&execOpts.RetryOnLock, "retry-on-lock", cfg.RetryOnLock, "Number of times to retry Terraform when it fails to acquire the state lock (0 disables retrying, which is safer for commands changing infrastructure)")
+	execCmd.Var(commaSeparatedValue{&execOpts.RetryLockCommands}, "retry-lock-commands", "Comma separated list of the Terraform commands which may be retried on lock errors")
+	execCmd.DurationVar(&execOpts.RetryLockBackoff, "retry-lock-backoff", 5*time.Second, "Delay before the first retry, doubled after each attempt")
+	hideFlags(execCmd, "cpu-profile", "trace")
+
+	whichOpts := whichOptions{}
+	whichCmd.BoolVar(&recursiveConstraints, "recursive-constraints", cfg.RecursiveConstraints, "Also honor required_version of local child modules")
+	whichCmd.BoolVar(&whichOpts.All, "all", false, "List every installed version satisfying the constraints")
+	whichCmd.BoolVar(&whichOpts.JSON, "json", false, "Output JSON")
+	whichCmd.BoolVar(&compactJSON, "compact", false, "Output single-line JSON (with --json)")
+	whichCmd.StringVar(&whichOpts.GitRef, "git-ref", "", "Resolve the constraints of the configuration as of this git ref rather than as checked out")
+
+	selectOpts := selectOptions{}
+	selectCmd.BoolVar(&selectOpts.Prerelease, "prerelease", cfg.IncludePrerelease, "Include pre-release versions")
+	selectCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	selectCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+	selectCmd.BoolVar(&verbose, "verbose", false, "Print details about what tvm is doing")
+	selectCmd.BoolVar(&selectOpts.StrictHooks, "strict-hooks", cfg.StrictHooks, "Fail the install if the post-install hook fails")
+
+	pruneOpts := pruneOptions{}
+	pruneFailedCmd.BoolVar(&pruneOpts.DryRun, "dry-run", false, "Only report the incomplete installs")
+	pruneFailedCmd.BoolVar(&pruneOpts.Reinstall, "reinstall", false, "Reinstall the incomplete versions instead of removing them")
+	pruneFailedCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	pruneFailedCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+	pruneFailedCmd.BoolVar(&pruneOpts.StrictHooks, "strict-hooks", cfg.StrictHooks, "Fail the reinstall if the post-install hook fails")
+
+	bundleOpts := bundleOptions{}
+	bundleCmd.Var(commaSeparatedValue{&bundleOpts.Versions}, "versions", "Comma separated list of the versions to bundle")
+	bundleCmd.StringVar(&bundleOpts.Platform, "platform", "", "Platform of the bundled versions, as os_arch (defaults to the current one)")
+	bundleCmd.StringVar(&bundleOpts.Output, "o", "", "Path of the bundle to write")
+	bundleCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	bundleCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+	bundleCmd.BoolVar(&verbose, "verbose", false, "Print details about what tvm is doing")
+
+	doctorOpts := doctorOptions{}
+	doctorCmd.BoolVar(&doctorOpts.Mirror, "mirror", false, "Check that the releases index, or the mirror of it, serves the expected layout")
+	doctorCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	doctorCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+
+	diffOpts := diffOptions{}
+	diffCmd.BoolVar(&diffOpts.Prerelease, "prerelease", cfg.IncludePrerelease, "Include pre-release versions")
+	diffCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	diffCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+
+	reinstallCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	reinstallCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+	reinstallCmd.BoolVar(&verbose, "verbose", false, "Print details about what tvm is doing")
+	reinstallStrictHooks := reinstallCmd.Bool("strict-hooks", cfg.StrictHooks, "Fail the reinstall if the post-install hook fails")
+
+	statusOpts := statusOptions{}
+	statusCmd.BoolVar(&statusOpts.JSON, "json", false, "Output JSON")
+	statusCmd.BoolVar(&compactJSON, "compact", false, "Output single-line JSON (with --json)")
+	statusCmd.BoolVar(&recursiveConstraints, "recursive-constraints", cfg.RecursiveConstraints, "Also honor required_version of local child modules")
+
+	runOpts := runOptions{}
+	runCmd.BoolVar(&allowInsecure, "allow-insecure", false, "Allow downloads over plaintext HTTP")
+	runCmd.BoolVar(&insecureSkipVerify, "insecure-skip-verify", cfg.InsecureSkipTLSVerify, "Don't verify the TLS certificates of HTTPS servers (last resort for internal mirrors with self-signed certificates)")
+	runCmd.BoolVar(&verbose, "verbose", false, "Print details about what tvm is doing")
+	runCmd.BoolVar(&runOpts.StrictHooks, "strict-hooks", cfg.StrictHooks, "Fail the install if the post-install hook fails")
+	runCmd.DurationVar(&runOpts.Timeout, "timeout", 0, "Stop Terraform, exiting with status 124, if it runs longer than this (Terraform then runs as a child of tvm instead of replacing it)")
+
+	gcOpts := gcOptions{}
+	gcCmd.BoolVar(&gcOpts.DryRun, "dry-run", false, "Only report what would be collected")
 
 	if path.Base(os.Args[0]) == "terraform" {
-		exec(os.Args[1:])
+		exec(os.Args[1:], execOpts)
 	} else if len(os.Args) >= 2 {
 		switch os.Args[1] {
 		case "list":
@@ -102,426 +272,589 @@ func main() {
 				fmt.Println(err)
 				os.Exit(1)
 			}
-			list()
+			if listOpts.Verify && !listOpts.Installed {
+				fmt.Println("--verify can only be used with --installed")
+				os.Exit(1)
+			}
+			if listOpts.Page < 1 {
+				fmt.Println("--page must be at least 1")
+				os.Exit(1)
+			}
+			if listOpts.Page > 1 && listOpts.PerPage <= 0 {
+				fmt.Println("--page can only be used with --per-page")
+				os.Exit(1)
+			}
+			if compactJSON && !listOpts.JSON {
+				fmt.Println("--compact can only be used with --json")
+				os.Exit(1)
+			}
+			if listOpts.Remote && listOpts.Installed {
+				fmt.Println("--remote and --installed are mutually exclusive")
+				os.Exit(1)
+			}
+			if *listFormat != "" {
+				if listOpts.JSON {
+					fmt.Println("--format and --json are mutually exclusive")
+					os.Exit(1)
+				}
+
+				format, err := parseFormat(*listFormat)
+
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+
+				listOpts.Format = format
+			}
+			if *listSince != "" {
+				since, err := version.NewVersion(*listSince)
+
+				if err != nil {
+					fmt.Printf("Invalid version %q for --since: %s\n", *listSince, err)
+					os.Exit(1)
+				}
+
+				listOpts.Since = since
+			}
+			list(listOpts)
 		case "install":
 			if err := installCmd.Parse(os.Args[2:]); err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
-			install()
+			if installOpts.BinaryName != "" {
+				if installOpts.ChecksumOnly || installOpts.RefreshOnly || installOpts.FromBundle != "" || installOpts.DependencyLock {
+					fmt.Println("--binary-name can't be used with --checksum-only, --refresh-only, --from-bundle or --dependency-lock")
+					os.Exit(1)
+				}
+				if err := checkBinaryName(installOpts.BinaryName); err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+			}
+			if installOpts.ChecksumOnly && (installOpts.RefreshOnly || installOpts.FromBundle != "" || installOpts.DependencyLock) {
+				fmt.Println("--checksum-only can't be used with --refresh-only, --from-bundle or --dependency-lock")
+				os.Exit(1)
+			}
+			if installOpts.RefreshOnly && (installOpts.FromBundle != "" || installOpts.DependencyLock || installOpts.WaitForRelease) {
+				fmt.Println("--refresh-only can't be used with --from-bundle, --dependency-lock or --wait-for-release")
+				os.Exit(1)
+			}
+			if err := install(installOpts); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
 		case "exec":
 			if err := execCmd.Parse(os.Args[2:]); err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
-			exec(os.Args[2:])
+			exec(execCmd.Args(), execOpts)
+		case "which":
+			if err := whichCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if compactJSON && !whichOpts.JSON {
+				fmt.Println("--compact can only be used with --json")
+				os.Exit(1)
+			}
+			which(whichOpts)
+		case "select":
+			if err := selectCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			selectVersion(selectOpts)
+		case "bundle":
+			if err := bundleCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if err := bundle(bundleOpts); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		case "reinstall":
+			if err := reinstallCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if reinstallCmd.NArg() != 1 {
+				fmt.Println("reinstall needs a version or a constraint")
+				os.Exit(1)
+			}
+			if err := reinstall(reinstallCmd.Arg(0), *reinstallStrictHooks); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		case "status":
+			if err := statusCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if compactJSON && !statusOpts.JSON {
+				fmt.Println("--compact can only be used with --json")
+				os.Exit(1)
+			}
+			status(statusOpts)
+		case "installed-path":
+			if err := installedPathCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if installedPathCmd.NArg() != 1 {
+				fmt.Println("installed-path needs exactly one version")
+				os.Exit(1)
+			}
+			installedPath(installedPathCmd.Arg(0))
+		case "run":
+			if err := runCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if runCmd.NArg() < 1 {
+				fmt.Println("run needs a version or a constraint, followed by the Terraform arguments")
+				os.Exit(1)
+			}
+			args := runCmd.Args()[1:]
+			if len(args) > 0 && args[0] == "--" {
+				args = args[1:]
+			}
+			run(runCmd.Arg(0), args, runOpts)
+		case "diff":
+			if err := diffCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if diffCmd.NArg() != 2 {
+				fmt.Println("diff needs two versions")
+				os.Exit(1)
+			}
+			from, err := version.NewVersion(diffCmd.Arg(0))
+
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			to, err := version.NewVersion(diffCmd.Arg(1))
+
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			diff(from, to, diffOpts)
+		case "doctor":
+			if err := doctorCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if !doctor(doctorOpts) {
+				os.Exit(1)
+			}
+		case "prune-failed":
+			if err := pruneFailedCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if err := pruneFailed(pruneOpts); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		case "gc":
+			if err := gcCmd.Parse(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			gc(gcOpts)
+
+			return
+		case "help", "-h", "-help", "--help":
+			help(commands, os.Args[2:])
+
+			return
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", os.Args[1])
+			printUsage(os.Stderr, commands)
+			os.Exit(1)
 		}
 	} else {
-		fmt.Println("Too few arguments")
+		fmt.Fprintf(os.Stderr, "Too few arguments\n\n")
+		printUsage(os.Stderr, commands)
 		os.Exit(1)
 	}
-}
 
-func scrape(url *url.URL) (*goquery.Document, error) {
-	resp, err := http.Get(url.String())
+	if cfg.GCAuto {
+		gc(gcOptions{Quiet: true})
+	}
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("Failed to get %s: %s", url, err)
+// warnAdvisories warns about the known security issues of tfVersion, unless
+// disabled with the advisories setting. It never fails the command.
+func warnAdvisories(m *tvm.Manager, tfVersion *version.Version) {
+	if !cfg.Advisories {
+		return
 	}
 
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Println("Error closing response body")
-		}
-	}()
+	advisories, err := m.Advisories(tfVersion)
+
+	if err != nil {
+		debugf("Not checking advisories: %s", err)
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Error getting %s: %s", url, resp.Status)
+		return
 	}
 
-	return goquery.NewDocumentFromReader(resp.Body)
+	for _, advisory := range advisories {
+		warnf("Terraform %s is affected by %s: %s, see %s", tfVersion, advisory.ID, advisory.Summary, advisory.URL)
+	}
 }
 
-func get() []tfVersion {
-	doc, err := scrape(baseURL)
+// execWithPath replaces tvm with the command args, looked up in PATH once
+// binDir has been put first in it. Windows can't replace a process, the
+// command runs as a child there and tvm exits with its exit code.
+func execWithPath(binDir string, args []string) {
+	if len(args) == 0 {
+		fmt.Println("--with-path needs a command to run")
+		os.Exit(1)
+	}
 
-	if err != nil {
+	if err := os.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH")); err != nil {
 		log.Fatal(err)
 	}
 
-	urls := make([]*url.URL, 0)
+	cmdPath, err := osexec.LookPath(args[0])
 
-	doc.Find("body ul li a").Each(func(i int, s *goquery.Selection) {
-		href, ok := s.Attr("href")
-
-		if !ok {
-			return
-		}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-		url, err := url.Parse(href)
+	if runtime.GOOS == "windows" {
+		exitCode, err := runTerraform(cmdPath, args[1:], os.Environ(), os.Stderr, 0)
 
 		if err != nil {
-			return
-		}
-
-		url = baseURL.ResolveReference(url)
-
-		if strings.HasPrefix(url.Path, baseURL.Path) {
-			urls = append(urls, url)
+			log.Fatal(err)
 		}
-	})
-
-	tfVersions := make([]tfVersion, 0)
-
-	c := make(chan tfVersion)
-
-	for _, _url := range urls {
-		go func(url *url.URL) {
-			doc, err := scrape(url)
-
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			tfVersion := tfVersion{}
-
-			doc.Find("body ul li a").Each(func(i int, s *goquery.Selection) {
-				_url, ok := s.Attr("href")
-
-				if !ok {
-					return
-				}
-
-				url, err := url.Parse(_url)
-
-				if err != nil {
-					return
-				}
-
-				if strings.HasSuffix(_url, "_SHA256SUMS") {
-					tfVersion.ChecksumURL = url
-
-					return
-				}
-
-				if strings.HasSuffix(_url, "_SHA256SUMS.sig") {
-					tfVersion.ChecksumSignatureURL = url
-
-					return
-				}
-
-				os, ok := s.Attr("data-os")
-
-				if !ok || os != runtime.GOOS {
-					return
-				}
-
-				arch, ok := s.Attr("data-arch")
-
-				if !ok || arch != runtime.GOARCH {
-					return
-				}
-
-				_version, ok := s.Attr("data-version")
-
-				if !ok {
-					return
-				}
 
-				version, err := version.NewVersion(_version)
-
-				if err != nil {
-					return
-				}
-
-				tfVersion.Version = version
-				tfVersion.URL = url
-			})
-
-			c <- tfVersion
-		}(_url)
+		os.Exit(exitCode)
 	}
 
-	for range urls {
-		tfVersion := <-c
-
-		if tfVersion.Version != nil {
-			tfVersions = append(tfVersions, tfVersion)
-		}
+	if err := syscall.Exec(cmdPath, args, os.Environ()); err != nil {
+		log.Fatal(err)
 	}
-
-	return tfVersions
 }
 
-func sortAsc(tfVersions []tfVersion) []tfVersion {
-	sort.Slice(tfVersions, func(i, j int) bool {
-		return tfVersions[i].Version.LessThan(tfVersions[j].Version)
-	})
-
-	return tfVersions
+func warnf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", a...)
 }
 
-func sortDsc(tfVersions []tfVersion) []tfVersion {
-	sort.Slice(tfVersions, func(i, j int) bool {
-		return tfVersions[j].Version.LessThan(tfVersions[i].Version)
-	})
-
-	return tfVersions
+func debugf(format string, a ...interface{}) {
+	if verbose {
+		fmt.Fprintf(os.Stderr, format+"\n", a...)
+	}
 }
 
-func list() {
-	tfVersions := sortAsc(get())
-
-	for _, tfVersion := range tfVersions {
-		fmt.Println(tfVersion.Version)
+// managerOptions returns the options of the tvm.Manager the commands work
+// with, as set by the configuration and the command line flags.
+func managerOptions() tvm.Options {
+	return tvm.Options{
+		BaseURL:              baseURL,
+		DataDir:              dataDirPath,
+		CacheDir:             cacheDirPath,
+		AllowInsecure:        allowInsecure,
+		InsecureSkipVerify:   insecureSkipVerify,
+		RecursiveConstraints: recursiveConstraints,
+		ProviderMinTerraform: providerMinTerraform,
+		IndexCacheTTL:        time.Duration(cfg.IndexCacheTTLMinutes) * time.Minute,
+		AllowedVersions:      allowedVersions,
+		DeniedVersions:       deniedVersions,
+		Logger:               cliLogger{},
 	}
 }
 
-func getConstraints() version.Constraints {
+func newManager() *tvm.Manager {
+	return tvm.New(managerOptions())
+}
+
+// workingDir returns the logical current directory, $PWD, when it is the
+// current directory, and the physical one otherwise. Symbolic links in the
+// path the user went through are thus kept, as Terraform does, so the
+// .terraform-version, .tvmrc and local module lookups are relative to the
+// directories the user sees rather than to the targets of the links.
+func workingDir() string {
 	currentDir, err := os.Getwd()
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	tfConfig, err := config.LoadDir(currentDir)
+	logicalDir := os.Getenv("PWD")
 
-	if err != nil {
-		log.Fatal(err)
+	if !filepath.IsAbs(logicalDir) {
+		return currentDir
 	}
 
-	if tfConfig.Terraform.RequiredVersion == "" {
-		return nil
+	logicalDirInfo, err := os.Stat(logicalDir)
+
+	if err != nil {
+		return currentDir
 	}
 
-	constraints, err := version.NewConstraint(tfConfig.Terraform.RequiredVersion)
+	currentDirInfo, err := os.Stat(currentDir)
 
-	if err != nil {
-		log.Fatal(err)
+	if err != nil || !os.SameFile(logicalDirInfo, currentDirInfo) {
+		return currentDir
 	}
 
-	return constraints
+	return filepath.Clean(logicalDir)
 }
 
-func install() {
-	tfVersions := sortDsc(get())
-
-	constraints := getConstraints()
+// installManagerOptions returns the manager options running the configured
+// post-install hook, whose failure only fails the install when strictHooks is
+// set.
+func installManagerOptions(force bool, strictHooks bool) tvm.Options {
+	managerOpts := managerOptions()
+	managerOpts.Force = force
 
-	for _, tfVersion := range tfVersions {
-		if constraints.Check(tfVersion.Version) {
-			tfVersionDirPath := path.Join(tfVersionsDirPath, tfVersion.Version.String())
+	if cfg.PostInstall != "" {
+		managerOpts.PostInstall = func(version *version.Version, binPath string) error {
+			err := runPostInstallHook(cfg.PostInstall, version, binPath)
 
-			if _, err := os.Stat(tfVersionDirPath); os.IsNotExist(err) {
-				err = os.Mkdir(tfVersionDirPath, 0755)
+			if err != nil && !strictHooks {
+				warnf("%s", err)
 
-				if err != nil {
-					log.Fatal(err)
-				}
+				return nil
 			}
 
-			archivePath := path.Join(cacheDirPath, path.Base(tfVersion.URL.Path))
-			archiveFile, err := os.Create(archivePath)
+			return err
+		}
+	}
 
-			if err != nil {
-				log.Fatal(err)
-			}
+	return managerOpts
+}
 
-			defer func() {
-				if err := archiveFile.Close(); err != nil {
-					fmt.Println("Error closing file")
-				}
+// checkBinaryName checks that name is the name of a file, rather than a
+// path which could point anywhere.
+func checkBinaryName(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("Invalid binary name %q, it must be a plain file name", name)
+	}
 
-				if err := os.Remove(archivePath); err != nil {
-					fmt.Println("Error removing file")
-				}
-			}()
+	return nil
+}
 
-			resp, err := http.Get(tfVersion.URL.String())
+func install(opts installOptions) error {
+	managerOpts := installManagerOptions(opts.Force, opts.StrictHooks)
+	metrics := newMetricsRecorder()
 
-			if err != nil {
-				log.Fatal(err)
-			}
+	if opts.MetricsFile != "" {
+		managerOpts.Timing = metrics.record
+	}
 
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					fmt.Println("Error closing response body")
-				}
-			}()
+	m := tvm.New(managerOpts)
 
-			h := sha256.New()
+	if opts.RefreshOnly {
+		return refreshIndex(m)
+	}
 
-			_, err = io.Copy(archiveFile, io.TeeReader(resp.Body, h))
+	if opts.FromBundle != "" {
+		return importBundle(m, opts.FromBundle)
+	}
 
-			if err != nil {
-				log.Fatal(err)
-			}
+	dir := opts.Dir
 
-			if tfVersion.ChecksumURL == nil {
-				fmt.Printf("No checksum found\n")
-			} else {
-				resp, err := http.Get(tfVersion.ChecksumURL.String())
+	if dir == "" {
+		dir = workingDir()
+	}
 
-				if err != nil {
-					log.Fatal(err)
-				}
+	constraints, err := m.Constraints(dir)
 
-				defer func() {
-					if err := resp.Body.Close(); err != nil {
-						fmt.Println("Error closing response body")
-					}
-				}()
+	if err != nil {
+		return err
+	}
 
-				for {
-					var checksum []byte
-					var filename string
+	if opts.ChecksumOnly {
+		_, err := m.CheckRelease(context.Background(), constraints)
 
-					n, err := fmt.Fscanf(resp.Body, "%64x  %s", &checksum, &filename)
+		return err
+	}
 
-					if err == io.EOF {
-						fmt.Printf("No checksum found\n")
+	if opts.BinaryName != "" {
+		_, err := m.InstallBinary(context.Background(), constraints, filepath.Join(opts.OutputDir, opts.BinaryName))
 
-						break
-					}
+		return err
+	}
 
-					if err != nil {
-						log.Fatal(err)
-					}
+	tfVersion, err := m.Install(context.Background(), constraints)
 
-					if n == 2 {
-						if filename == path.Base(tfVersion.URL.Path) {
-							if !bytes.Equal(h.Sum(nil), checksum) {
-								fmt.Printf("Checksum verification failed\n")
+	if err == tvm.ErrNoMatchingVersion && opts.WaitForRelease {
+		tfVersion, err = waitForRelease(managerOpts, constraints, opts.WaitTimeout, opts.PollInterval)
+	}
 
-								return
-							}
+	if err == nil {
+		warnAdvisories(m, tfVersion)
+	}
 
-							break
-						}
-					} else {
-						fmt.Printf("Bad format\n")
-					}
-				}
+	if opts.MetricsFile != "" {
+		if err := metrics.writeFile(opts.MetricsFile); err != nil {
+			warnf("Failed to write metrics: %s", err)
+		}
+	}
 
-			}
+	if err != nil || !opts.DependencyLock {
+		return err
+	}
 
-			archive, err := zip.OpenReader(archivePath)
+	return lockDependencies(dir, tfVersion, m.BinaryPath(tfVersion), opts.LockPlatforms)
+}
 
-			if err != nil {
-				log.Fatal(err)
-			}
+// refreshIndex refreshes the index cache, so that the next commands neither
+// wait for the releases index nor need the network while the cache is fresh.
+func refreshIndex(m *tvm.Manager) error {
+	releases, err := m.RefreshIndex(context.Background())
 
-			defer func() {
-				if err := archive.Close(); err != nil {
-					fmt.Println("Error closing archive")
-				}
-			}()
+	if err != nil {
+		return err
+	}
 
-			for _, file := range archive.File {
-				if file.FileHeader.Name == "terraform" {
-					src, err := file.Open()
+	if len(releases) == 0 {
+		fmt.Println("Refreshed the list of available versions: none is available")
 
-					if err != nil {
-						log.Fatal(err)
-					}
+		return nil
+	}
 
-					defer func() {
-						if err := src.Close(); err != nil {
-							fmt.Println("Error closing source file")
-						}
-					}()
+	fmt.Printf("Refreshed the list of available versions: %d versions, the newest being %s\n", len(releases), releases[len(releases)-1].Version)
 
-					dst, err := os.Create(path.Join(tfVersionDirPath, path.Base(file.FileHeader.Name)))
+	return nil
+}
 
-					if err != nil {
-						log.Fatal(err)
-					}
+// runningMessage is the line exec prints before running Terraform. The time
+// is in UTC so that the message doesn't depend on the environment.
+func runningMessage(tfVersion *version.Version, source string, now time.Time) string {
+	return fmt.Sprintf("tvm: running Terraform %s (resolved from %s) at %s\n", tfVersion, source, now.UTC().Format(time.RFC3339))
+}
 
-					defer func() {
-						if err := dst.Close(); err != nil {
-							fmt.Println("Error closing destination file")
-						}
-					}()
+func exec(args []string, opts execOptions) {
+	stopProfiling, err := startProfiling(opts.CPUProfile, opts.Trace)
 
-					_, err = io.Copy(dst, src)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-					if err != nil {
-						log.Fatal(err)
-					}
+	m := newManager()
 
-					err = dst.Chmod(0755)
+	dir, cleanup, err := constraintsDir(opts.GitRef)
 
-					if err != nil {
-						log.Fatal(err)
-					}
-				}
-			}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-			fmt.Printf("Successfully installed Terraform version %s\n", tfVersion.Version)
+	tfVersion, source, err := m.ResolveWithSource(dir)
 
-			return
+	if err == tvm.ErrNoInstalledVersion && cfg.AutoInstall {
+		infoOutput = os.Stderr
+
+		if err := install(installOptions{Dir: dir, StrictHooks: cfg.StrictHooks}); err != nil {
+			cleanup()
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+
+		tfVersion, source, err = m.ResolveWithSource(dir)
 	}
 
-	fmt.Printf("None of the available Terraform versions matched the constraints\n")
-}
+	cleanup()
+
+	if opts.GitRef != "" {
+		source += " at " + opts.GitRef
+	}
 
-func exec(args []string) {
-	tfVersionsDir, err := os.Open(tfVersionsDirPath)
+	if err == tvm.ErrNoInstalledVersion && opts.SystemFallback {
+		stopProfiling()
+		execSystemTerraform(args)
+	}
+
+	if err == tvm.ErrNoInstalledVersion {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer func() {
-		if err := tfVersionsDir.Close(); err != nil {
-			fmt.Println("Error closing Terraform versions directory")
-		}
-	}()
+	tfVersionBinPath := m.BinaryPath(tfVersion)
 
-	tfVersionDirPaths, err := tfVersionsDir.Readdir(-1)
+	if _, err := os.Stat(tfVersionBinPath); os.IsNotExist(err) {
+		fmt.Printf("Found Terraform version %s but Terraform binary is missing\n", tfVersion)
+		os.Exit(1)
+	}
 
-	if err != nil {
-		log.Fatal(err)
+	if err := m.CheckPolicy(tfVersion); err != nil {
+		if cfg.BlockDeniedExec {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		warnf("%s", err)
 	}
 
-	tfVersions := make([]tfVersion, len(tfVersionDirPaths))
+	warnAdvisories(m, tfVersion)
 
-	for i, tfVersionDirPath := range tfVersionDirPaths {
-		version, err := version.NewVersion(tfVersionDirPath.Name())
+	if opts.StateCheck || opts.StrictState {
+		mismatch, err := checkState(workingDir(), tfVersion)
 
 		if err != nil {
-			log.Fatal(err)
+			warnf("State check: %s", err)
+		} else if mismatch != "" && opts.StrictState {
+			fmt.Println(mismatch)
+			os.Exit(1)
+		} else if mismatch != "" {
+			warnf("%s", mismatch)
 		}
+	}
 
-		tfVersions[i] = tfVersion{
-			Version: version,
-		}
+	if !opts.Quiet {
+		fmt.Fprint(os.Stderr, runningMessage(tfVersion, source, time.Now()))
 	}
 
-	sortDsc(tfVersions)
+	audit(tfVersion, source, args)
+	stopProfiling()
 
-	constraints := getConstraints()
+	handOff(tfVersionBinPath, args, opts)
+}
 
-	for _, tfVersion := range tfVersions {
-		if constraints.Check(tfVersion.Version) {
-			tfVersionBinPath := path.Join(tfVersionsDirPath, tfVersion.Version.String(), "terraform")
+// handOff runs the Terraform binary at tfVersionBinPath with args as set by
+// opts, replacing tvm when possible, and never returns.
+func handOff(tfVersionBinPath string, args []string, opts execOptions) {
+	if opts.WithPath {
+		execWithPath(path.Dir(tfVersionBinPath), args)
+	}
 
-			if _, err := os.Stat(tfVersionBinPath); os.IsNotExist(err) {
-				fmt.Printf("Found Terraform version %s but Terraform binary is missing\n", tfVersion.Version)
-				break
-			}
+	env := os.Environ()
 
-			args := append([]string{"terraform"}, args...)
-			env := os.Environ()
+	if opts.RetryOnLock > 0 && isRetryableCommand(args, opts.RetryLockCommands) {
+		os.Exit(runTerraformRetryingOnLock(tfVersionBinPath, args, env, opts))
+	}
 
-			err = syscall.Exec(tfVersionBinPath, args, env)
+	if opts.Timeout > 0 || runtime.GOOS == "windows" {
+		exitCode, err := runTerraform(tfVersionBinPath, args, env, os.Stderr, opts.Timeout)
 
-			if err != nil {
-				log.Fatal(err)
-			}
+		if err != nil {
+			log.Fatal(err)
 		}
+
+		os.Exit(exitCode)
 	}
 
-	fmt.Printf("None of the installed Terraform versions matched the constraints\n")
+	args = append([]string{"terraform"}, args...)
+
+	if err := syscall.Exec(tfVersionBinPath, args, env); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkingDirKeepsSymlinks(t *testing.T) {
+	rootDir, err := filepath.EvalSymlinks(t.TempDir())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targetDir := filepath.Join(rootDir, "target")
+	linkDir := filepath.Join(rootDir, "link")
+
+	if err := os.Mkdir(targetDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(targetDir, linkDir); err != nil {
+		t.Skip(err)
+	}
+
+	chdir(t, linkDir)
+
+	if got := workingDir(); got != linkDir {
+		t.Errorf("workingDir() = %s, want the logical %s", got, linkDir)
+	}
+
+	// A $PWD which isn't the current directory, as left by a program which
+	// changed it without updating $PWD, is ignored.
+	t.Setenv("PWD", rootDir)
+
+	if got := workingDir(); got != targetDir {
+		t.Errorf("workingDir() = %s with a stale $PWD, want the physical %s", got, targetDir)
+	}
+
+	t.Setenv("PWD", "relative")
+
+	if got := workingDir(); got != targetDir {
+		t.Errorf("workingDir() = %s with a relative $PWD, want the physical %s", got, targetDir)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// metricsRecorder adds up the time spent in each phase reported by the
+// tvm.Manager, to write them for CI to scrape.
+type metricsRecorder struct {
+	phases    []string
+	durations map[string]time.Duration
+}
+
+func newMetricsRecorder() *metricsRecorder {
+	return &metricsRecorder{
+		durations: map[string]time.Duration{},
+	}
+}
+
+func (r *metricsRecorder) record(phase string, duration time.Duration) {
+	if _, ok := r.durations[phase]; !ok {
+		r.phases = append(r.phases, phase)
+	}
+
+	r.durations[phase] += duration
+}
+
+// writeFile writes the durations to filePath in the OpenMetrics text format.
+func (r *metricsRecorder) writeFile(filePath string) error {
+	metrics := strings.Builder{}
+	metrics.WriteString("# HELP tvm_phase_duration_seconds Time spent in each phase of the install.\n")
+	metrics.WriteString("# TYPE tvm_phase_duration_seconds gauge\n")
+
+	for _, phase := range r.phases {
+		fmt.Fprintf(&metrics, "tvm_phase_duration_seconds{phase=%q} %f\n", phase, r.durations[phase].Seconds())
+	}
+
+	metrics.WriteString("# EOF\n")
+
+	return ioutil.WriteFile(filePath, []byte(metrics.String()), 0644)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"text/template"
+
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+// listEntry is a version as output by list and which, in JSON or through
+// the --format template.
+type listEntry struct {
+	Version   string           `json:"version"`
+	Path      string           `json:"path,omitempty"`
+	Status    tvm.VerifyStatus `json:"status,omitempty"`
+	Installed bool             `json:"installed,omitempty"`
+	Selected  bool             `json:"selected,omitempty"`
+}
+
+// parseFormat parses a --format template, which is applied to each
+// listEntry, and checks that it can be applied to one.
+func parseFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").Parse(format + "\n")
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --format template: %s", err)
+	}
+
+	if err := tmpl.Execute(ioutil.Discard, listEntry{}); err != nil {
+		return nil, fmt.Errorf("Invalid --format template: %s", err)
+	}
+
+	return tmpl, nil
+}
+
+func printFormatted(tmpl *template.Template, entries []listEntry) {
+	for _, entry := range entries {
+		if err := tmpl.Execute(os.Stdout, entry); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// infoOutput is where cliLogger prints information, exec switches it to
+// stderr to keep the output of Terraform clean.
+var infoOutput io.Writer = os.Stdout
+
+// cliLogger prints the progress messages of the tvm.Manager the way the
+// commands do: information on stdout, warnings and debug messages on stderr.
+type cliLogger struct{}
+
+func (cliLogger) Debugf(format string, a ...interface{}) {
+	debugf(format, a...)
+}
+
+func (cliLogger) Infof(format string, a ...interface{}) {
+	fmt.Fprintf(infoOutput, format+"\n", a...)
+}
+
+func (cliLogger) Warnf(format string, a ...interface{}) {
+	warnf(format, a...)
+}
+
+// jsonSchemaVersion is the version of the JSON outputs. Fields are only
+// added to them, removing or changing one requires bumping it.
+const jsonSchemaVersion = 1
+
+// compactJSON makes printJSON output single-line JSON.
+var compactJSON bool
+
+// jsonList is the JSON output of the commands listing versions.
+type jsonList struct {
+	SchemaVersion int         `json:"schema_version"`
+	Versions      []listEntry `json:"versions"`
+	Page          *listPage   `json:"page,omitempty"`
+}
+
+// listPage describes the page of versions listed with --per-page.
+type listPage struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+	Pages   int `json:"pages"`
+}
+
+// jsonEntry is the JSON output of the commands outputting a single version.
+type jsonEntry struct {
+	SchemaVersion int `json:"schema_version"`
+	listEntry
+}
+
+func printJSONList(entries []listEntry, page *listPage) {
+	printJSON(jsonList{SchemaVersion: jsonSchemaVersion, Versions: entries, Page: page})
+}
+
+func printJSONEntry(entry listEntry) {
+	printJSON(jsonEntry{SchemaVersion: jsonSchemaVersion, listEntry: entry})
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+
+	if !compactJSON {
+		encoder.SetIndent("", "  ")
+	}
+
+	if err := encoder.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
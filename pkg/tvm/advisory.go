@@ -0,0 +1,62 @@
+package tvm
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/hashicorp/go-version"
+)
+
+// advisoriesFS holds advisories.json, the list of known security issues the
+// maintainers refresh along with releases.
+//
+//go:embed advisories.json
+var advisoriesFS embed.FS
+
+// Advisory is a known security issue affecting a range of versions.
+type Advisory struct {
+	ID       string `json:"id"`
+	Versions string `json:"versions"`
+	Summary  string `json:"summary"`
+	URL      string `json:"url"`
+}
+
+// Advisories returns the advisories affecting v. They are read from
+// AdvisoriesPath when the file exists, and from the list embedded in tvm
+// otherwise.
+func (m *Manager) Advisories(v *version.Version) ([]Advisory, error) {
+	data, err := ioutil.ReadFile(m.opts.AdvisoriesPath)
+
+	if os.IsNotExist(err) {
+		data, err = advisoriesFS.ReadFile("advisories.json")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	advisories := make([]Advisory, 0)
+
+	if err := json.Unmarshal(data, &advisories); err != nil {
+		return nil, fmt.Errorf("Failed to parse advisories: %s", err)
+	}
+
+	affecting := make([]Advisory, 0)
+
+	for _, advisory := range advisories {
+		constraints, err := version.NewConstraint(advisory.Versions)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid versions of advisory %s: %s", advisory.ID, err)
+		}
+
+		if Check(constraints, v) {
+			affecting = append(affecting, advisory)
+		}
+	}
+
+	return affecting, nil
+}
@@ -0,0 +1,311 @@
+package tvm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+const bundleManifestName = "manifest.json"
+
+// BundleManifest is the first entry of a bundle, describing the versions it
+// holds. Each version comes as its terraform binary and metadata.json, in a
+// directory named after it, as in the versions directory.
+type BundleManifest struct {
+	Platform  string    `json:"platform"`
+	Versions  []string  `json:"versions"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Bundle downloads and verifies versions for the platform of the Manager,
+// as Install does, and writes them as a gzipped tar archive to w, to be
+// imported with ImportBundle on a machine without network access.
+func (m *Manager) Bundle(ctx context.Context, versions []*version.Version, w io.Writer) error {
+	stagingDirPath, err := ioutil.TempDir(m.opts.CacheDir, "bundle")
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := os.RemoveAll(stagingDirPath); err != nil {
+			m.logger.Warnf("Error removing temporary directory")
+		}
+	}()
+
+	// The staging Manager has its own cache directory too, so that its
+	// downloads, for another platform, don't clash with those of m.
+	opts := m.opts
+	opts.DataDir = path.Join(stagingDirPath, "data")
+	opts.CacheDir = path.Join(stagingDirPath, "cache")
+	opts.Force = false
+	opts.PostInstall = nil
+	staging := New(opts)
+
+	manifest := BundleManifest{
+		Platform:  m.platform(),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := os.Mkdir(opts.CacheDir, 0755); err != nil {
+		return err
+	}
+
+	for _, v := range versions {
+		exact, err := version.NewConstraint("= " + v.String())
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := staging.Install(ctx, exact); err != nil {
+			return fmt.Errorf("Failed to get Terraform %s: %s", v, err)
+		}
+
+		manifest.Versions = append(manifest.Versions, v.String())
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := writeTarEntry(tarWriter, bundleManifestName, 0644, manifestData); err != nil {
+		return err
+	}
+
+	for _, v := range versions {
+		for _, name := range []string{"terraform", metadataFileName} {
+			mode := int64(0644)
+
+			if name == "terraform" {
+				mode = 0755
+			}
+
+			if err := m.writeTarFile(tarWriter, path.Join(v.String(), name), mode, path.Join(staging.VersionDir(v), name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+
+	return gzipWriter.Close()
+}
+
+func writeTarEntry(tarWriter *tar.Writer, name string, mode int64, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := tarWriter.Write(data)
+
+	return err
+}
+
+// writeTarFile streams the file at filePath to a tar entry, the binaries
+// being too large to be read in memory.
+func (m *Manager) writeTarFile(tarWriter *tar.Writer, name string, mode int64, filePath string) error {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			m.logger.Warnf("Error closing %s", filePath)
+		}
+	}()
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    info.Size(),
+		ModTime: time.Now(),
+	}
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tarWriter, file)
+
+	return err
+}
+
+// ImportBundle installs the versions of a bundle written by Bundle and
+// returns them. The bundle must be for the platform of the Manager, and
+// every binary must match the hash in its metadata. Versions which are
+// already installed are kept, unless Force is set. If the import fails, the
+// version directories it created are removed.
+//
+// The hashes only prove the integrity of the bundle, not its authenticity:
+// the signatures were verified when it was written, but anyone can write a
+// bundle whose metadata matches its binaries. A bundle is thus to be trusted
+// as much as where it comes from.
+func (m *Manager) ImportBundle(r io.Reader) (_ []*version.Version, err error) {
+	gzipReader, err := gzip.NewReader(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+
+	header, err := tarReader.Next()
+
+	if err != nil || header.Name != bundleManifestName {
+		return nil, fmt.Errorf("Invalid bundle: %s must come first", bundleManifestName)
+	}
+
+	manifest := BundleManifest{}
+
+	if err := json.NewDecoder(tarReader).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %s", bundleManifestName, err)
+	}
+
+	if manifest.Platform != m.platform() {
+		return nil, fmt.Errorf("Bundle is for %s, not %s", manifest.Platform, m.platform())
+	}
+
+	versions := make([]*version.Version, 0, len(manifest.Versions))
+	imported := map[string]bool{}
+	createdDirPaths := make([]string, 0, len(manifest.Versions))
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		for _, dirPath := range createdDirPaths {
+			if err := os.RemoveAll(dirPath); err != nil {
+				m.logger.Warnf("Error removing Terraform version directory")
+			}
+		}
+	}()
+
+	for _, raw := range manifest.Versions {
+		v, err := version.NewVersion(raw)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid version %q in %s: %s", raw, bundleManifestName, err)
+		}
+
+		if err := m.CheckPolicy(v); err != nil {
+			return nil, err
+		}
+
+		if !m.opts.Force && m.IsInstalled(v) {
+			m.logger.Infof("Terraform %s already installed", v)
+
+			continue
+		}
+
+		if _, err := os.Stat(m.VersionDir(v)); os.IsNotExist(err) {
+			createdDirPaths = append(createdDirPaths, m.VersionDir(v))
+		}
+
+		if err := os.MkdirAll(m.VersionDir(v), 0755); err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, v)
+		imported[v.String()] = true
+	}
+
+	for {
+		header, err := tarReader.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		dir, name := path.Split(header.Name)
+		dir = path.Clean(dir)
+
+		if name != "terraform" && name != metadataFileName {
+			return nil, fmt.Errorf("Invalid bundle entry %s", header.Name)
+		}
+
+		v, err := version.NewVersion(dir)
+
+		if err != nil || v.String() != dir {
+			return nil, fmt.Errorf("Invalid bundle entry %s", header.Name)
+		}
+
+		if !imported[dir] {
+			continue
+		}
+
+		if err := m.importFile(tarReader, path.Join(m.VersionDir(v), name), os.FileMode(header.Mode)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range versions {
+		if m.Verify(v) != VerifyOK {
+			if err := os.RemoveAll(m.VersionDir(v)); err != nil {
+				m.logger.Warnf("Error removing Terraform version directory")
+			}
+
+			return nil, fmt.Errorf("Terraform %s of the bundle doesn't match its metadata", v)
+		}
+	}
+
+	for _, v := range versions {
+		m.logger.Infof("Successfully imported Terraform version %s", v)
+	}
+
+	return versions, nil
+}
+
+func (m *Manager) importFile(src io.Reader, dstPath string, mode os.FileMode) error {
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode&0755)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := dst.Close(); err != nil {
+			m.logger.Warnf("Error closing destination file")
+		}
+	}()
+
+	_, err = io.Copy(dst, src)
+
+	return err
+}
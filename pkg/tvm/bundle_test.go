@@ -0,0 +1,132 @@
+package tvm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+)
+
+type bundleTestEntry struct {
+	name string
+	mode int64
+	data []byte
+}
+
+// testBundle returns a bundle for linux_amd64 holding the entries after its
+// manifest listing versions.
+func testBundle(t *testing.T, versions []string, entries []bundleTestEntry) *bytes.Reader {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifest, err := json.Marshal(BundleManifest{Platform: "linux_amd64", Versions: versions})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeTarEntry(tarWriter, bundleManifestName, 0644, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		if err := writeTarEntry(tarWriter, entry.name, entry.mode, entry.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+// testBundleVersion returns the entries of version v with binary, whose
+// metadata records binaryHash.
+func testBundleVersion(t *testing.T, v string, binary []byte, binaryHash []byte) []bundleTestEntry {
+	t.Helper()
+
+	metadata, err := json.Marshal(Metadata{Version: v, BinarySHA256: hex.EncodeToString(binaryHash)})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return []bundleTestEntry{
+		{v + "/terraform", 0755, binary},
+		{v + "/" + metadataFileName, 0644, metadata},
+	}
+}
+
+func newBundleTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	return New(Options{DataDir: dir, CacheDir: dir, OS: "linux", Arch: "amd64"})
+}
+
+func TestImportBundle(t *testing.T) {
+	binary := []byte("#!/bin/sh\n")
+	hash := sha256.Sum256(binary)
+
+	m := newBundleTestManager(t)
+	bundle := testBundle(t, []string{"1.5.7"}, testBundleVersion(t, "1.5.7", binary, hash[:]))
+
+	versions, err := m.ImportBundle(bundle)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 1 || versions[0].String() != "1.5.7" {
+		t.Fatalf("imported %v, want 1.5.7", versions)
+	}
+
+	if status := m.Verify(versions[0]); status != VerifyOK {
+		t.Errorf("imported 1.5.7 is %s", status)
+	}
+}
+
+func TestImportBundleFailureRemovesCreatedDirs(t *testing.T) {
+	binary := []byte("#!/bin/sh\n")
+	hash := sha256.Sum256(binary)
+	entries := testBundleVersion(t, "1.5.7", binary, hash[:])
+
+	tests := []struct {
+		name    string
+		entries []bundleTestEntry
+	}{
+		{"invalid entry", append(entries, bundleTestEntry{"1.6.6/README", 0644, nil})},
+		{"hash mismatch", append(entries, testBundleVersion(t, "1.6.6", binary, make([]byte, sha256.Size))...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newBundleTestManager(t)
+
+			if _, err := m.ImportBundle(testBundle(t, []string{"1.5.7", "1.6.6"}, tt.entries)); err == nil {
+				t.Fatal("invalid bundle imported")
+			}
+
+			for _, v := range []string{"1.5.7", "1.6.6"} {
+				if _, err := os.Stat(path.Join(m.VersionsDir(), v)); !os.IsNotExist(err) {
+					t.Errorf("%s left behind: %v", v, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package tvm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform/config"
+)
+
+// maxModuleDepth bounds how deep RecursiveConstraints follows local module
+// sources.
+const maxModuleDepth = 10
+
+// Constraints returns the version constraints of the Terraform configuration
+// in dir. A version pinned in its .terraform-version file is added to the
+// required_version, so a pin contradicting it matches nothing.
+func (m *Manager) Constraints(dir string) (version.Constraints, error) {
+	constraints, _, err := m.constraintsAndPin(dir)
+
+	return constraints, err
+}
+
+// constraintsAndPin is like Constraints but also reports whether a version
+// is pinned.
+func (m *Manager) constraintsAndPin(dir string) (version.Constraints, bool, error) {
+	constraints, err := m.loadConstraints(dir, 0, map[string]bool{})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	pinned, err := m.pinnedConstraint(dir)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return append(constraints, pinned...), pinned != nil, nil
+}
+
+// loadConstraints parses the required_version of the configuration in dir,
+// together with the lower bound implied by its required_providers when
+// ProviderMinTerraform is set.
+// With RecursiveConstraints, the required_version of the child modules
+// sourced from local paths are added as well, so that the result is only
+// satisfied by versions every module accepts. Remote modules are skipped.
+func (m *Manager) loadConstraints(dir string, depth int, visited map[string]bool) (version.Constraints, error) {
+	visited[dir] = true
+
+	tfConfig, err := config.LoadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var constraints version.Constraints
+
+	if tfConfig.Terraform != nil && tfConfig.Terraform.RequiredVersion != "" {
+		constraints, err = version.NewConstraint(tfConfig.Terraform.RequiredVersion)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid required_version in %s: %s", dir, err)
+		}
+	}
+
+	providerConstraints, err := m.providerConstraints(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	constraints = append(constraints, providerConstraints...)
+
+	if !m.opts.RecursiveConstraints {
+		return constraints, nil
+	}
+
+	for _, module := range tfConfig.Modules {
+		if !isLocalSource(module.Source) {
+			continue
+		}
+
+		moduleDir := filepath.Join(dir, module.Source)
+
+		if visited[moduleDir] {
+			continue
+		}
+
+		if depth >= maxModuleDepth {
+			m.logger.Warnf("Not following module %s which is nested more than %d levels deep", moduleDir, maxModuleDepth)
+
+			continue
+		}
+
+		moduleConstraints, err := m.loadConstraints(moduleDir, depth+1, visited)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load module %s: %s", module.Name, err)
+		}
+
+		constraints = append(constraints, moduleConstraints...)
+	}
+
+	return constraints, nil
+}
+
+// Check reports whether v satisfies the constraints. Unlike
+// constraints.Check, a pre-release is only accepted by constraints made only
+// of != operators when one of them names a pre-release, which would
+// otherwise select a pre-release over the latest stable version. An empty
+// list of constraints still accepts anything.
+func Check(constraints version.Constraints, v *version.Version) bool {
+	if !constraints.Check(v) {
+		return false
+	}
+
+	if len(constraints) == 0 || v.Prerelease() == "" {
+		return true
+	}
+
+	for _, constraint := range constraints {
+		if constraint.Prerelease() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLocalSource reports whether a module source is a local path, which is
+// how Terraform tells them apart from registry and remote sources.
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
@@ -0,0 +1,79 @@
+package tvm
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+func TestCheck(t *testing.T) {
+	versions := []string{"1.2.9", "1.3.0", "1.5.0", "1.5.7", "1.6.6", "1.7.0-beta1", "1.7.0"}
+
+	tests := []struct {
+		constraints string
+		want        string
+	}{
+		{"", "1.7.0"},
+		{">= 1.3, < 1.7, != 1.5.0", "1.6.6"},
+		{">= 1.3, < 1.6, != 1.5.7", "1.5.0"},
+		{"~> 1.5.0", "1.5.7"},
+		{"~> 1.5", "1.7.0"},
+		{"!= 1.7.0", "1.6.6"},
+		{"!= 1.7.0, != 1.6.6", "1.5.7"},
+		{"= 1.7.0-beta1", "1.7.0-beta1"},
+		{">= 1.7.0-beta1, < 1.7.0-rc1", "1.7.0-beta1"},
+		{">= 1.7.0-beta1", "1.7.0"},
+		{"!= 1.7.0, != 1.7.0-beta2", "1.7.0-beta1"},
+		{"< 1.7.0", "1.6.6"},
+		{">= 1.8", ""},
+		{">= 1.3, < 1.5, != 1.3.0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraints, func(t *testing.T) {
+			var constraints version.Constraints
+
+			if tt.constraints != "" {
+				var err error
+
+				constraints, err = version.NewConstraint(tt.constraints)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := ""
+
+			for i := len(versions) - 1; i >= 0; i-- {
+				if Check(constraints, version.Must(version.NewVersion(versions[i]))) {
+					got = versions[i]
+
+					break
+				}
+			}
+
+			if got != tt.want {
+				t.Errorf("newest version matching %q = %q, want %q", tt.constraints, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckEmptyConstraintsAcceptPrerelease(t *testing.T) {
+	if !Check(nil, version.Must(version.NewVersion("1.7.0-beta1"))) {
+		t.Error("a pre-release isn't accepted without constraints")
+	}
+}
+
+func TestCheckNotEqualRejectsPrerelease(t *testing.T) {
+	constraints, err := version.NewConstraint("!= 1.6.6")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Check(constraints, version.Must(version.NewVersion("1.7.0-beta1"))) {
+		t.Error("a pre-release is accepted by != 1.6.6")
+	}
+}
@@ -0,0 +1,12 @@
+// Package tvm lists, installs and selects Terraform versions. It is the
+// library behind the tvm command, which can be used as a reference of how it
+// is meant to be used.
+//
+// A Manager works on a data directory, where versions are installed, and a
+// cache directory. The examples show how to list the available versions,
+// install the one a configuration requires and find the installed version to
+// run, as tvm exec does.
+//
+// The exported API of this package is kept backward compatible: new
+// features come as new Options fields and methods.
+package tvm
@@ -0,0 +1,130 @@
+package tvm_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+func Example() {
+	m := tvm.New(tvm.Options{
+		DataDir:       "/var/lib/tvm",
+		CacheDir:      "/var/cache/tvm",
+		IndexCacheTTL: time.Hour,
+	})
+
+	fmt.Println(m.VersionDir(version.Must(version.NewVersion("1.5.7"))))
+	// Output: /var/lib/tvm/versions/1.5.7
+}
+
+// Listing the versions available from the releases index.
+func ExampleManager_ListRemote() {
+	m := tvm.New(tvm.Options{DataDir: "/var/lib/tvm", CacheDir: "/var/cache/tvm"})
+
+	releases, err := m.ListRemote(context.Background())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, release := range releases {
+		fmt.Println(release.Version)
+	}
+}
+
+// Installing the newest version satisfying the required_version of a
+// configuration and getting the path of its binary.
+func ExampleManager_Install() {
+	m := tvm.New(tvm.Options{DataDir: "/var/lib/tvm", CacheDir: "/var/cache/tvm"})
+
+	constraints, err := m.Constraints(".")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	v, err := m.Install(context.Background(), constraints)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(m.BinaryPath(v))
+}
+
+func ExampleManager_Constraints() {
+	dir := exampleDir(map[string]string{
+		"main.tf": "terraform {\n  required_version = \">= 1.3, < 1.7\"\n}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	m := tvm.New(tvm.Options{})
+
+	constraints, err := m.Constraints(dir)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(constraints)
+	// Output: >= 1.3, < 1.7
+}
+
+// Finding the installed version to run in a directory, as tvm exec does.
+func ExampleManager_Resolve() {
+	dataDir := exampleDir(map[string]string{
+		"versions/1.5.7/terraform": "",
+		"versions/1.6.6/terraform": "",
+	})
+	defer os.RemoveAll(dataDir)
+
+	dir := exampleDir(map[string]string{
+		"main.tf": "terraform {\n  required_version = \"~> 1.5.0\"\n}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	m := tvm.New(tvm.Options{DataDir: dataDir, CacheDir: dataDir})
+
+	v, err := m.Resolve(dir)
+
+	if err == tvm.ErrNoInstalledVersion {
+		// install one first
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(v)
+	// Output: 1.5.7
+}
+
+// exampleDir returns a temporary directory holding files.
+func exampleDir(files map[string]string) string {
+	dir, err := ioutil.TempDir("", "tvm-example")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			log.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filePath, []byte(content), 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return dir
+}
@@ -0,0 +1,123 @@
+package tvm
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Fetcher retrieves the content of URLs of a given scheme. HTTP(S) is always
+// available, other schemes are registered by the optional fetchers built in
+// with the matching build tag (s3, gcs) or by programs embedding tvm.
+type Fetcher interface {
+	Fetch(ctx context.Context, url *url.URL) (io.ReadCloser, error)
+}
+
+var fetchers = map[string]Fetcher{
+	"http":  httpFetcher{},
+	"https": httpFetcher{},
+}
+
+// RegisterFetcher makes fetcher handle the URLs of scheme. It is meant to be
+// called from init functions, before any Manager is used.
+func RegisterFetcher(scheme string, fetcher Fetcher) {
+	fetchers[scheme] = fetcher
+}
+
+// open checks that url may be downloaded and hands it to the fetcher of its
+// scheme. The caller has to close the returned body.
+func (m *Manager) open(ctx context.Context, url *url.URL) (io.ReadCloser, error) {
+	if err := m.checkURLScheme(url); err != nil {
+		return nil, err
+	}
+
+	if url.Scheme == "https" && m.opts.InsecureSkipVerify {
+		m.insecureSkipVerifyWarning.Do(func() {
+			m.logger.Warnf("Not verifying TLS certificates, anyone on the network path can tamper with the downloads, whose integrity relies on checksum and signature verification only")
+		})
+
+		return httpFetcher{client: m.insecureSkipVerifyClient}.Fetch(ctx, url)
+	}
+
+	fetcher := fetchers[url.Scheme]
+
+	// The built-in HTTP(S) fetcher uses the client of the Manager, which
+	// checks the scheme of redirects too.
+	if _, ok := fetcher.(httpFetcher); ok {
+		fetcher = httpFetcher{client: m.httpClient}
+	}
+
+	return fetcher.Fetch(ctx, url)
+}
+
+// newHTTPClient returns a client using transport which follows redirects
+// only to URLs checkURLScheme accepts, so that an HTTPS server can't send a
+// download over plaintext HTTP unless AllowInsecure is set.
+func (m *Manager) newHTTPClient(transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("Stopped after 10 redirects")
+			}
+
+			return m.checkURLScheme(req.URL)
+		},
+	}
+}
+
+// insecureSkipVerifyTransport doesn't verify the certificates of HTTPS
+// servers, for Options.InsecureSkipVerify.
+var insecureSkipVerifyTransport = newInsecureSkipVerifyTransport()
+
+func newInsecureSkipVerifyTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+	return transport
+}
+
+// httpFetcher fetches HTTP(S) URLs with client. The entries of fetchers have
+// none, open sets the client of the Manager.
+type httpFetcher struct {
+	client *http.Client
+}
+
+func (f httpFetcher) Fetch(ctx context.Context, url *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get %s: %s", url, err)
+	}
+
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+
+		return nil, fmt.Errorf("Error getting %s: %s", url, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// objectKey maps a bucket URL to the key of the object to fetch, the
+// index.html object standing for directories.
+func objectKey(url *url.URL) string {
+	key := strings.TrimPrefix(url.Path, "/")
+
+	if key == "" || strings.HasSuffix(key, "/") {
+		key += "index.html"
+	}
+
+	return key
+}
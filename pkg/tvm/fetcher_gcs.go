@@ -0,0 +1,54 @@
+//go:build gcs
+// +build gcs
+
+package tvm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	"cloud.google.com/go/storage"
+)
+
+func init() {
+	RegisterFetcher("gs", gcsFetcher{})
+}
+
+// gcsFetcher serves gs://bucket/object URLs using the application default
+// credentials. Like with S3, directory URLs are served from their index.html
+// object.
+type gcsFetcher struct{}
+
+func (gcsFetcher) Fetch(ctx context.Context, url *url.URL) (io.ReadCloser, error) {
+	client, err := storage.NewClient(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := client.Bucket(url.Host).Object(objectKey(url)).NewReader(ctx)
+
+	if err != nil {
+		client.Close()
+
+		return nil, fmt.Errorf("Failed to get %s: %s", url, err)
+	}
+
+	return gcsObject{reader, client}, nil
+}
+
+// gcsObject closes the storage client along with the object reader.
+type gcsObject struct {
+	*storage.Reader
+	client *storage.Client
+}
+
+func (o gcsObject) Close() error {
+	if err := o.Reader.Close(); err != nil {
+		return err
+	}
+
+	return o.client.Close()
+}
@@ -0,0 +1,45 @@
+//go:build s3
+// +build s3
+
+package tvm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func init() {
+	RegisterFetcher("s3", s3Fetcher{})
+}
+
+// s3Fetcher serves s3://bucket/key URLs using the default AWS credential
+// chain. The bucket is expected to mirror the layout of the releases site,
+// directory URLs being served from their index.html object.
+type s3Fetcher struct{}
+
+func (s3Fetcher) Fetch(ctx context.Context, url *url.URL) (io.ReadCloser, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s3.New(sess).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(url.Host),
+		Key:    aws.String(objectKey(url)),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get %s: %s", url, err)
+	}
+
+	return out.Body, nil
+}
@@ -0,0 +1,86 @@
+package tvm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestOpenRedirectToPlaintextHTTP has an HTTPS mirror redirect the download
+// to a plaintext HTTP server.
+func TestOpenRedirectToPlaintextHTTP(t *testing.T) {
+	plaintextServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("terraform"))
+	}))
+	t.Cleanup(plaintextServer.Close)
+
+	tlsServer := httptest.NewTLSServer(http.RedirectHandler(plaintextServer.URL+"/terraform.zip", http.StatusFound))
+	t.Cleanup(tlsServer.Close)
+
+	downloadURL, err := url.Parse(tlsServer.URL + "/terraform.zip")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		allowInsecure bool
+		wantErr       bool
+	}{
+		{"refused", false, true},
+		{"allowed", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(Options{AllowInsecure: tt.allowInsecure})
+
+			// The certificate of the test server is only trusted by its
+			// client, whose transport the Manager uses here.
+			m.httpClient = m.newHTTPClient(tlsServer.Client().Transport)
+
+			body, err := m.open(context.Background(), downloadURL)
+
+			if err == nil {
+				if err := body.Close(); err != nil {
+					t.Error(err)
+				}
+			}
+
+			if tt.wantErr && err == nil {
+				t.Error("download redirected to plaintext HTTP")
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("error = %s, want none", err)
+			}
+		})
+	}
+}
+
+func TestOpenInsecureSkipVerifyRedirectToPlaintextHTTP(t *testing.T) {
+	plaintextServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("terraform"))
+	}))
+	t.Cleanup(plaintextServer.Close)
+
+	tlsServer := httptest.NewTLSServer(http.RedirectHandler(plaintextServer.URL+"/terraform.zip", http.StatusFound))
+	t.Cleanup(tlsServer.Close)
+
+	downloadURL, err := url.Parse(tlsServer.URL + "/terraform.zip")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(Options{InsecureSkipVerify: true})
+
+	if body, err := m.open(context.Background(), downloadURL); err == nil {
+		_ = body.Close()
+
+		t.Error("download redirected to plaintext HTTP")
+	}
+}
@@ -0,0 +1,57 @@
+package tvm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockPollInterval is how often a lock held by another process is tried
+// again.
+const lockPollInterval = 50 * time.Millisecond
+
+// withFileLock runs fn while holding an exclusive lock on the file at
+// lockFilePath, waiting for other processes holding it to release it first,
+// unless ctx is done before.
+func (m *Manager) withFileLock(ctx context.Context, lockFilePath string, fn func() error) error {
+	lockFile, err := os.OpenFile(lockFilePath, os.O_RDWR|os.O_CREATE, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := lockFile.Close(); err != nil {
+			m.logger.Warnf("Error closing lock file")
+		}
+	}()
+
+	m.logger.Debugf("Waiting for lock %s", lockFilePath)
+
+	for {
+		locked, err := tryLockFileExclusive(lockFile)
+
+		if err != nil {
+			return err
+		}
+
+		if locked {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Gave up waiting for lock %s: %w", lockFilePath, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	defer func() {
+		if err := unlockFile(lockFile); err != nil {
+			m.logger.Warnf("Error unlocking %s", lockFilePath)
+		}
+	}()
+
+	return fn()
+}
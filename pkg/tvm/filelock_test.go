@@ -0,0 +1,121 @@
+package tvm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithFileLockGivesUpWhenContextIsDone(t *testing.T) {
+	lockFilePath := filepath.Join(t.TempDir(), "index.json.lock")
+	m := New(Options{})
+
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+
+	go func() {
+		done <- m.withFileLock(context.Background(), lockFilePath, func() error {
+			close(locked)
+			<-release
+
+			return nil
+		})
+	}()
+
+	<-locked
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := m.withFileLock(ctx, lockFilePath, func() error {
+		t.Error("lock taken while it is held")
+
+		return nil
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want to give up once the context is done", err)
+	}
+
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.withFileLock(context.Background(), lockFilePath, func() error { return nil }); err != nil {
+		t.Errorf("lock not taken once released: %s", err)
+	}
+}
+
+// TestListRemoteConcurrentRefreshers simulates processes listing the remote
+// versions at the same time with a stale index cache, each with its own
+// Manager and lock file descriptor: only one of them fetches the index.
+func TestListRemoteConcurrentRefreshers(t *testing.T) {
+	var fetches int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		time.Sleep(100 * time.Millisecond)
+
+		_, _ = w.Write([]byte(testJSONIndex))
+	}))
+	t.Cleanup(server.Close)
+
+	baseURL, err := ParseBaseURL(server.URL + "/terraform")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := t.TempDir()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			m := New(Options{
+				BaseURL:       baseURL,
+				CacheDir:      cacheDir,
+				OS:            "linux",
+				Arch:          "amd64",
+				AllowInsecure: true,
+				IndexCacheTTL: time.Hour,
+			})
+			m.index = jsonIndexReader{}
+
+			releases, err := m.ListRemote(context.Background())
+
+			if err == nil && len(releases) != 2 {
+				err = errors.New("wrong number of releases")
+			}
+
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("index fetched %d times, want once", n)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package tvm
+
+import (
+	"os"
+	"syscall"
+)
+
+// tryLockFileExclusive locks f without waiting, returning false when another
+// process holds the lock.
+func tryLockFileExclusive(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
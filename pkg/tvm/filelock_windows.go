@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package tvm
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// tryLockFileExclusive locks f without waiting, returning false when another
+// process holds the lock.
+func tryLockFileExclusive(f *os.File) (bool, error) {
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &windows.Overlapped{})
+
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
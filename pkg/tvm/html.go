@@ -0,0 +1,251 @@
+//go:build html
+// +build html
+
+package tvm
+
+// With the html build tag, the releases index is read by scraping its HTML
+// pages instead of its index.json file, for mirrors only serving the former.
+// goquery is only used here, so that the default build doesn't link it.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/hashicorp/go-version"
+)
+
+func init() {
+	defaultIndexReader = htmlIndexReader{}
+}
+
+// htmlIndexReader scrapes the HTML index, linking to a page per version,
+// itself linking to the archives, SHA256SUMS file and signatures.
+type htmlIndexReader struct{}
+
+func (htmlIndexReader) readIndex(ctx context.Context, m *Manager) ([]Release, error) {
+	return m.get(ctx)
+}
+
+func (htmlIndexReader) checkIndex(ctx context.Context, m *Manager) ([]MirrorCheck, Release, *url.URL) {
+	doc, err := m.scrape(ctx, m.opts.BaseURL)
+	checks := []MirrorCheck{{Name: "index", URL: m.opts.BaseURL, Err: err}}
+
+	if err != nil {
+		return checks, Release{}, nil
+	}
+
+	pageURL, pageVersion := newestVersionPage(m.versionPageURLs(doc))
+
+	if pageURL == nil {
+		checks = append(checks, MirrorCheck{Name: "index", URL: m.opts.BaseURL, Err: errors.New("No version page linked")})
+
+		return checks, Release{}, nil
+	}
+
+	doc, err = m.scrape(ctx, pageURL)
+	checks = append(checks, MirrorCheck{Name: "version page", URL: pageURL, Err: err})
+
+	if err != nil {
+		return checks, Release{}, nil
+	}
+
+	release := m.parseVersionPage(pageURL, doc)
+
+	if release.URL == nil {
+		checks = append(checks, MirrorCheck{Name: "archive", URL: pageURL, Err: fmt.Errorf("No archive of %s for %s linked", pageVersion, m.platform())})
+	}
+
+	return checks, release, pageURL
+}
+
+func (m *Manager) scrape(ctx context.Context, url *url.URL) (*goquery.Document, error) {
+	body, err := m.open(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := body.Close(); err != nil {
+			m.logger.Warnf("Error closing response body")
+		}
+	}()
+
+	return goquery.NewDocumentFromReader(body)
+}
+
+// get scrapes the releases index and every version page it links to.
+func (m *Manager) get(ctx context.Context) ([]Release, error) {
+	baseURL := m.opts.BaseURL
+
+	doc, err := m.scrape(ctx, baseURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	urls := m.versionPageURLs(doc)
+
+	type result struct {
+		release Release
+		err     error
+	}
+
+	releases := make([]Release, 0)
+
+	c := make(chan result)
+
+	for _, _url := range urls {
+		go func(url *url.URL) {
+			doc, err := m.scrape(ctx, url)
+
+			if err != nil {
+				c <- result{err: err}
+
+				return
+			}
+
+			c <- result{release: m.parseVersionPage(url, doc)}
+		}(_url)
+	}
+
+	for range urls {
+		result := <-c
+
+		if result.err != nil {
+			if err == nil {
+				err = result.err
+			}
+
+			continue
+		}
+
+		if result.release.Version != nil {
+			releases = append(releases, result.release)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+// versionPageURLs returns the links of the releases index below the base URL,
+// which are the version pages.
+func (m *Manager) versionPageURLs(doc *goquery.Document) []*url.URL {
+	baseURL := m.opts.BaseURL
+	urls := make([]*url.URL, 0)
+
+	doc.Find("body ul li a").Each(func(i int, s *goquery.Selection) {
+		href, ok := s.Attr("href")
+
+		if !ok {
+			return
+		}
+
+		url, err := url.Parse(href)
+
+		if err != nil {
+			return
+		}
+
+		url = baseURL.ResolveReference(url)
+
+		if strings.HasPrefix(url.Path, baseURL.Path) {
+			urls = append(urls, url)
+		}
+	})
+
+	return urls
+}
+
+// parseVersionPage returns the release described by the version page at
+// pageURL, whose links may be relative to it as mirrors often serve them.
+func (m *Manager) parseVersionPage(pageURL *url.URL, doc *goquery.Document) Release {
+	release := Release{}
+
+	doc.Find("body ul li a").Each(func(i int, s *goquery.Selection) {
+		_url, ok := s.Attr("href")
+
+		if !ok {
+			return
+		}
+
+		url, err := url.Parse(_url)
+
+		if err != nil {
+			return
+		}
+
+		url = pageURL.ResolveReference(url)
+
+		if strings.HasSuffix(url.Path, "_SHA256SUMS") {
+			release.ChecksumURL = url
+
+			return
+		}
+
+		if isChecksumSignature(url) {
+			release.ChecksumSignatureURLs = append(release.ChecksumSignatureURLs, url)
+
+			return
+		}
+
+		os, ok := s.Attr("data-os")
+
+		if !ok || os != m.opts.OS {
+			return
+		}
+
+		arch, ok := s.Attr("data-arch")
+
+		if !ok || arch != m.opts.Arch {
+			return
+		}
+
+		_version, ok := s.Attr("data-version")
+
+		if !ok {
+			return
+		}
+
+		version, err := version.NewVersion(_version)
+
+		if err != nil {
+			return
+		}
+
+		release.Version = version
+		release.URL = url
+	})
+
+	return release
+}
+
+// newestVersionPage returns the version page of the newest stable version
+// among urls, named after their last path element, with its version.
+func newestVersionPage(urls []*url.URL) (*url.URL, *version.Version) {
+	var newestURL *url.URL
+	var newestVersion *version.Version
+
+	for _, url := range urls {
+		v, err := version.NewVersion(path.Base(url.Path))
+
+		if err != nil || v.Prerelease() != "" {
+			continue
+		}
+
+		if newestVersion == nil || v.GreaterThan(newestVersion) {
+			newestURL, newestVersion = url, v
+		}
+	}
+
+	return newestURL, newestVersion
+}
@@ -0,0 +1,51 @@
+//go:build html
+// +build html
+
+package tvm
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testVersionPage = `<html><body><ul>
+<li><a href="../">../</a></li>
+<li><a data-os="linux" data-arch="amd64" data-version="1.5.7" href="terraform_1.5.7_linux_amd64.zip">terraform_1.5.7_linux_amd64.zip</a></li>
+<li><a href="terraform_1.5.7_SHA256SUMS">terraform_1.5.7_SHA256SUMS</a></li>
+<li><a href="terraform_1.5.7_SHA256SUMS.72D7468F.sig">terraform_1.5.7_SHA256SUMS.72D7468F.sig</a></li>
+<li><a href="terraform_1.5.7_SHA256SUMS.sig">terraform_1.5.7_SHA256SUMS.sig</a></li>
+</ul></body></html>`
+
+func TestParseVersionPageSignatures(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(testVersionPage))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pageURL, err := url.Parse("https://mirror.example.com/terraform/1.5.7/")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release := New(Options{OS: "linux", Arch: "amd64"}).parseVersionPage(pageURL, doc)
+
+	want := []string{
+		"https://mirror.example.com/terraform/1.5.7/terraform_1.5.7_SHA256SUMS.72D7468F.sig",
+		"https://mirror.example.com/terraform/1.5.7/terraform_1.5.7_SHA256SUMS.sig",
+	}
+
+	if len(release.ChecksumSignatureURLs) != len(want) {
+		t.Fatalf("signature urls = %v, want %v", release.ChecksumSignatureURLs, want)
+	}
+
+	for i, signatureURL := range release.ChecksumSignatureURLs {
+		if signatureURL.String() != want[i] {
+			t.Errorf("signature url = %s, want %s", signatureURL, want[i])
+		}
+	}
+}
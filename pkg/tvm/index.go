@@ -0,0 +1,191 @@
+package tvm
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// indexCache is the list of available versions read from the releases
+// index, saved so that listing and installing don't need to fetch it each
+// time.
+type indexCache struct {
+	BaseURL  string       `json:"base_url"`
+	Platform string       `json:"platform"`
+	Versions []indexEntry `json:"versions"`
+}
+
+type indexEntry struct {
+	Version               string   `json:"version"`
+	URL                   string   `json:"url"`
+	ChecksumURL           string   `json:"checksum_url,omitempty"`
+	ChecksumSignatureURLs []string `json:"checksum_signature_urls,omitempty"`
+}
+
+func (m *Manager) indexCachePath() string {
+	return path.Join(m.opts.CacheDir, "index.json")
+}
+
+func (m *Manager) platform() string {
+	return m.opts.OS + "_" + m.opts.Arch
+}
+
+// IndexCacheAge returns how long ago the index cache was written, and false
+// when there is none. The cache may be for another base URL or platform.
+func (m *Manager) IndexCacheAge() (time.Duration, bool) {
+	info, err := os.Stat(m.indexCachePath())
+
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Since(info.ModTime()).Round(time.Second), true
+}
+
+// readIndexCache returns the cached releases when the cache is younger than
+// IndexCacheTTL and was built from the current base URL and platform.
+func (m *Manager) readIndexCache() ([]Release, time.Duration, bool) {
+	if m.opts.IndexCacheTTL == 0 {
+		return nil, 0, false
+	}
+
+	info, err := os.Stat(m.indexCachePath())
+
+	if err != nil {
+		return nil, 0, false
+	}
+
+	age := time.Since(info.ModTime()).Round(time.Second)
+
+	if age > m.opts.IndexCacheTTL {
+		return nil, age, false
+	}
+
+	data, err := ioutil.ReadFile(m.indexCachePath())
+
+	if err != nil {
+		return nil, age, false
+	}
+
+	cache := indexCache{}
+
+	if err := json.Unmarshal(data, &cache); err != nil || cache.BaseURL != m.opts.BaseURL.String() || cache.Platform != m.platform() {
+		return nil, age, false
+	}
+
+	releases := make([]Release, 0, len(cache.Versions))
+
+	for _, entry := range cache.Versions {
+		release, err := entry.release()
+
+		if err != nil {
+			return nil, age, false
+		}
+
+		releases = append(releases, release)
+	}
+
+	return releases, age, true
+}
+
+func (m *Manager) writeIndexCache(releases []Release) error {
+	cache := indexCache{
+		BaseURL:  m.opts.BaseURL.String(),
+		Platform: m.platform(),
+		Versions: make([]indexEntry, len(releases)),
+	}
+
+	for i, release := range releases {
+		cache.Versions[i] = newIndexEntry(release)
+	}
+
+	data, err := json.Marshal(cache)
+
+	if err != nil {
+		return err
+	}
+
+	// The cache is replaced atomically, as it is read without the lock.
+	tmpFile, err := ioutil.TempFile(m.opts.CacheDir, "index")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		os.Remove(tmpFile.Name())
+
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), m.indexCachePath())
+}
+
+func newIndexEntry(release Release) indexEntry {
+	entry := indexEntry{
+		Version: release.Version.String(),
+		URL:     release.URL.String(),
+	}
+
+	if release.ChecksumURL != nil {
+		entry.ChecksumURL = release.ChecksumURL.String()
+	}
+
+	for _, signatureURL := range release.ChecksumSignatureURLs {
+		entry.ChecksumSignatureURLs = append(entry.ChecksumSignatureURLs, signatureURL.String())
+	}
+
+	return entry
+}
+
+func (entry indexEntry) release() (Release, error) {
+	release := Release{}
+
+	version, err := version.NewVersion(entry.Version)
+
+	if err != nil {
+		return release, err
+	}
+
+	release.Version = version
+
+	if release.URL, err = url.Parse(entry.URL); err != nil {
+		return release, err
+	}
+
+	if entry.ChecksumURL != "" {
+		if release.ChecksumURL, err = url.Parse(entry.ChecksumURL); err != nil {
+			return release, err
+		}
+	}
+
+	for _, rawSignatureURL := range entry.ChecksumSignatureURLs {
+		signatureURL, err := url.Parse(rawSignatureURL)
+
+		if err != nil {
+			return release, err
+		}
+
+		release.ChecksumSignatureURLs = append(release.ChecksumSignatureURLs, signatureURL)
+	}
+
+	return release, nil
+}
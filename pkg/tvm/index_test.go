@@ -0,0 +1,48 @@
+package tvm
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+func TestReadIndexCacheTTL(t *testing.T) {
+	baseURL, err := ParseBaseURL(DefaultBaseURL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archiveURL, err := url.Parse(DefaultBaseURL + "1.5.7/terraform_1.5.7_linux_amd64.zip")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := t.TempDir()
+	releases := []Release{{Version: version.Must(version.NewVersion("1.5.7")), URL: archiveURL}}
+
+	if err := New(Options{BaseURL: baseURL, CacheDir: cacheDir}).writeIndexCache(releases); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ttl  time.Duration
+		want bool
+	}{
+		{time.Hour, true},
+		{0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ttl.String(), func(t *testing.T) {
+			m := New(Options{BaseURL: baseURL, CacheDir: cacheDir, IndexCacheTTL: tt.ttl})
+
+			if _, _, ok := m.readIndexCache(); ok != tt.want {
+				t.Errorf("cache used: %t, want %t", ok, tt.want)
+			}
+		})
+	}
+}
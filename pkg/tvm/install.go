@@ -0,0 +1,482 @@
+package tvm
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+var (
+	// ErrNoMatchingVersion is returned by Install when none of the available
+	// versions satisfies the constraints.
+	ErrNoMatchingVersion = errors.New("None of the available Terraform versions matched the constraints")
+
+	// ErrChecksumVerification is returned when a downloaded archive doesn't
+	// match its SHA256SUMS entry.
+	ErrChecksumVerification = errors.New("Checksum verification failed")
+)
+
+// Install installs the newest available version satisfying the constraints
+// and permitted by the policy, unless it is already installed, and returns
+// it. If versions satisfy the constraints but none of them is permitted, the
+// *PolicyError of the newest one is returned.
+func (m *Manager) Install(ctx context.Context, constraints version.Constraints) (*version.Version, error) {
+	release, err := m.selectRelease(ctx, constraints)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return release.Version, m.installRelease(ctx, release)
+}
+
+// Reinstall installs the version Install would select afresh, even if it is
+// already installed. The existing installation is only removed once the new
+// one has succeeded, and is put back otherwise. It returns the version and
+// whether an existing installation was replaced.
+func (m *Manager) Reinstall(ctx context.Context, constraints version.Constraints) (*version.Version, bool, error) {
+	release, err := m.selectRelease(ctx, constraints)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	tfVersionDirPath := m.VersionDir(release.Version)
+	backupDirPath := path.Join(m.opts.DataDir, ".reinstall-"+release.Version.String())
+
+	if err := os.RemoveAll(backupDirPath); err != nil {
+		return nil, false, err
+	}
+
+	replaced := true
+
+	if err := os.Rename(tfVersionDirPath, backupDirPath); os.IsNotExist(err) {
+		replaced = false
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if err := m.installRelease(ctx, release); err != nil {
+		if replaced {
+			if err := os.RemoveAll(tfVersionDirPath); err != nil {
+				m.logger.Warnf("Error removing Terraform version directory")
+			}
+
+			if err := os.Rename(backupDirPath, tfVersionDirPath); err != nil {
+				m.logger.Warnf("Error restoring Terraform version directory from %s", backupDirPath)
+			}
+		}
+
+		return nil, false, err
+	}
+
+	if err := os.RemoveAll(backupDirPath); err != nil {
+		m.logger.Warnf("Error removing %s", backupDirPath)
+	}
+
+	return release.Version, replaced, nil
+}
+
+// CheckRelease downloads the archive of the version Install would select
+// and verifies it against the SHA256SUMS file of the release and its
+// signature, as Install does, then discards it without installing anything.
+// A release without a SHA256SUMS file fails the verification.
+func (m *Manager) CheckRelease(ctx context.Context, constraints version.Constraints) (*version.Version, error) {
+	release, err := m.selectRelease(ctx, constraints)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if release.ChecksumURL == nil {
+		return nil, fmt.Errorf("%s: no SHA256SUMS file for Terraform %s", ErrChecksumVerification, release.Version)
+	}
+
+	archivePath := path.Join(m.opts.CacheDir, path.Base(release.URL.Path))
+
+	defer func() {
+		if err := os.Remove(archivePath); err != nil && !os.IsNotExist(err) {
+			m.logger.Warnf("Error removing file")
+		}
+	}()
+
+	start := time.Now()
+	archiveHash, err := m.download(ctx, release.URL, archivePath)
+	m.observe("download", start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	err = m.verifyChecksum(ctx, release, archiveHash)
+	m.observe("verify", start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Infof("%s of Terraform %s matches its checksum", path.Base(release.URL.Path), release.Version)
+
+	return release.Version, nil
+}
+
+// InstallBinary downloads and verifies the version Install would select, as
+// Install does, but extracts its binary to binPath instead of installing it
+// in DataDir. No PostInstall hook is run.
+func (m *Manager) InstallBinary(ctx context.Context, constraints version.Constraints, binPath string) (*version.Version, error) {
+	release, err := m.selectRelease(ctx, constraints)
+
+	if err != nil {
+		return nil, err
+	}
+
+	archivePath := path.Join(m.opts.CacheDir, path.Base(release.URL.Path))
+
+	defer func() {
+		if err := os.Remove(archivePath); err != nil && !os.IsNotExist(err) {
+			m.logger.Warnf("Error removing file")
+		}
+	}()
+
+	start := time.Now()
+	archiveHash, err := m.download(ctx, release.URL, archivePath)
+	m.observe("download", start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	err = m.verifyChecksum(ctx, release, archiveHash)
+	m.observe("verify", start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	_, err = m.extract(archivePath, binPath)
+	m.observe("extract", start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Infof("Successfully extracted Terraform version %s to %s", release.Version, binPath)
+
+	return release.Version, nil
+}
+
+// selectRelease returns the newest available release satisfying the
+// constraints and permitted by the policy.
+func (m *Manager) selectRelease(ctx context.Context, constraints version.Constraints) (Release, error) {
+	releases, err := m.ListRemote(ctx)
+
+	if err != nil {
+		return Release{}, err
+	}
+
+	var policyErr error
+
+	for i := len(releases) - 1; i >= 0; i-- {
+		if !Check(constraints, releases[i].Version) {
+			continue
+		}
+
+		if err := m.CheckPolicy(releases[i].Version); err != nil {
+			m.logger.Debugf("Skipping %s", err)
+
+			if policyErr == nil {
+				policyErr = err
+			}
+
+			continue
+		}
+
+		return releases[i], nil
+	}
+
+	if policyErr != nil {
+		return Release{}, policyErr
+	}
+
+	return Release{}, ErrNoMatchingVersion
+}
+
+func (m *Manager) installRelease(ctx context.Context, release Release) error {
+	tfVersionDirPath := m.VersionDir(release.Version)
+
+	if !m.opts.Force && m.IsInstalled(release.Version) {
+		m.logger.Infof("Terraform %s already installed", release.Version)
+
+		return nil
+	}
+
+	if err := os.MkdirAll(tfVersionDirPath, 0755); err != nil {
+		return err
+	}
+
+	// The archive is always downloaded afresh, overwriting whatever an
+	// interrupted run may have left, so a truncated leftover is never used.
+	archivePath := path.Join(m.opts.CacheDir, path.Base(release.URL.Path))
+
+	defer func() {
+		if err := os.Remove(archivePath); err != nil && !os.IsNotExist(err) {
+			m.logger.Warnf("Error removing file")
+		}
+	}()
+
+	start := time.Now()
+	archiveHash, err := m.download(ctx, release.URL, archivePath)
+	m.observe("download", start)
+
+	if err != nil {
+		return err
+	}
+
+	start = time.Now()
+	err = m.verifyChecksum(ctx, release, archiveHash)
+	m.observe("verify", start)
+
+	if err != nil {
+		return err
+	}
+
+	start = time.Now()
+	binaryHash, err := m.extract(archivePath, m.BinaryPath(release.Version))
+	m.observe("extract", start)
+
+	if err != nil {
+		return err
+	}
+
+	metadata := Metadata{
+		Version:       release.Version.String(),
+		URL:           release.URL.String(),
+		ArchiveSHA256: hex.EncodeToString(archiveHash),
+		BinarySHA256:  hex.EncodeToString(binaryHash),
+		InstalledAt:   time.Now().UTC(),
+	}
+
+	if err := writeMetadata(tfVersionDirPath, metadata); err != nil {
+		return err
+	}
+
+	if m.opts.PostInstall != nil {
+		if err := m.opts.PostInstall(release.Version, m.BinaryPath(release.Version)); err != nil {
+			if err := os.RemoveAll(tfVersionDirPath); err != nil {
+				m.logger.Warnf("Error removing Terraform version directory")
+			}
+
+			return err
+		}
+	}
+
+	m.logger.Infof("Successfully installed Terraform version %s", release.Version)
+
+	return nil
+}
+
+// download saves the archive at url to archivePath and returns its SHA256.
+func (m *Manager) download(ctx context.Context, url *url.URL, archivePath string) ([]byte, error) {
+	body, err := m.open(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := body.Close(); err != nil {
+			m.logger.Warnf("Error closing response body")
+		}
+	}()
+
+	archiveFile, err := os.Create(archivePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := archiveFile.Close(); err != nil {
+			m.logger.Warnf("Error closing file")
+		}
+	}()
+
+	h := sha256.New()
+
+	if _, err := io.Copy(archiveFile, io.TeeReader(body, h)); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// verifyChecksum checks the SHA256 of the downloaded archive against the
+// SHA256SUMS file of the release. The whole file is read and its signature,
+// which covers all of it, is verified before any of its lines is trusted. An
+// archive which isn't listed fails the verification.
+func (m *Manager) verifyChecksum(ctx context.Context, release Release, archiveHash []byte) error {
+	if release.ChecksumURL == nil {
+		if _, err := os.Stat(m.opts.TrustedKeyringPath); err == nil {
+			m.logger.Warnf("No checksum found, whose signature could be verified")
+
+			return ErrSignatureVerification
+		}
+
+		m.logger.Infof("No checksum found")
+
+		return nil
+	}
+
+	checksums, err := m.fetch(ctx, release.ChecksumURL)
+
+	if err != nil {
+		return err
+	}
+
+	if err := m.verifyChecksumSignatures(ctx, checksums, release.ChecksumSignatureURLs); err != nil {
+		return err
+	}
+
+	sums, err := parseChecksums(checksums)
+
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %s", path.Base(release.ChecksumURL.Path), err)
+	}
+
+	checksum, ok := sums[path.Base(release.URL.Path)]
+
+	if !ok {
+		return fmt.Errorf("%s: %s is not listed in %s", ErrChecksumVerification, path.Base(release.URL.Path), path.Base(release.ChecksumURL.Path))
+	}
+
+	if !bytes.Equal(archiveHash, checksum) {
+		return ErrChecksumVerification
+	}
+
+	return nil
+}
+
+// parseChecksums returns the SHA256 of each file listed in a SHA256SUMS
+// file, made of "<hex SHA256>  <file name>" lines.
+func parseChecksums(checksums []byte) (map[string][]byte, error) {
+	sums := map[string][]byte{}
+
+	for i, line := range strings.Split(string(checksums), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Bad format on line %d", i+1)
+		}
+
+		checksum, err := hex.DecodeString(fields[0])
+
+		if err != nil || len(checksum) != sha256.Size {
+			return nil, fmt.Errorf("Bad checksum on line %d", i+1)
+		}
+
+		sums[fields[1]] = checksum
+	}
+
+	return sums, nil
+}
+
+// extract writes the terraform binary of the archive to binPath and returns
+// its SHA256.
+func (m *Manager) extract(archivePath string, binPath string) ([]byte, error) {
+	archive, err := zip.OpenReader(archivePath)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid archive %s: %s", path.Base(archivePath), err)
+	}
+
+	defer func() {
+		if err := archive.Close(); err != nil {
+			m.logger.Warnf("Error closing archive")
+		}
+	}()
+
+	binaryHash := sha256.New()
+
+	for _, file := range archive.File {
+		if file.FileHeader.Name == "terraform" {
+			if err := m.extractFile(file, binPath, binaryHash); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return binaryHash.Sum(nil), nil
+}
+
+func (m *Manager) extractFile(file *zip.File, dstPath string, h io.Writer) error {
+	src, err := file.Open()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := src.Close(); err != nil {
+			m.logger.Warnf("Error closing source file")
+		}
+	}()
+
+	dst, err := os.Create(dstPath)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := dst.Close(); err != nil {
+			m.logger.Warnf("Error closing destination file")
+		}
+	}()
+
+	if _, err := io.Copy(dst, io.TeeReader(src, h)); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	mode := extractedMode(file)
+
+	m.logger.Debugf("Setting mode %s on %s", mode, dstPath)
+
+	return dst.Chmod(mode)
+}
+
+// extractedMode returns the permissions of an extracted archive entry: those
+// recorded in the archive, without the write permission for group and others
+// and always readable by the owner, the terraform binary being executable.
+func extractedMode(file *zip.File) os.FileMode {
+	mode := file.Mode().Perm()&0755 | 0400
+
+	if path.Base(file.FileHeader.Name) == "terraform" {
+		mode |= 0111
+	}
+
+	return mode
+}
@@ -0,0 +1,181 @@
+package tvm
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+// writeTestArchive writes a release archive holding a terraform binary
+// recorded with mode.
+func writeTestArchive(t *testing.T, archivePath string, mode os.FileMode, binary []byte) {
+	t.Helper()
+
+	archiveFile, err := os.Create(archivePath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zipWriter := zip.NewWriter(archiveFile)
+	header := &zip.FileHeader{Name: "terraform", Method: zip.Deflate}
+	header.SetMode(mode)
+
+	w, err := zipWriter.CreateHeader(header)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(binary); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := archiveFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractMakesBinaryExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no executable permission on Windows")
+	}
+
+	tests := []struct {
+		name string
+		mode os.FileMode
+		want os.FileMode
+	}{
+		{"executable", 0755, 0755},
+		{"not executable", 0644, 0755},
+		{"writable by all", 0777, 0755},
+		{"owner only", 0700, 0711},
+		{"no permissions", 0, 0511},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archivePath := filepath.Join(dir, "terraform.zip")
+			binPath := filepath.Join(dir, "terraform")
+
+			writeTestArchive(t, archivePath, tt.mode, []byte("#!/bin/sh\n"))
+
+			if _, err := New(Options{}).extract(archivePath, binPath); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(binPath)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := info.Mode().Perm(); got != tt.want {
+				t.Errorf("mode = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestInstallOverwritesTruncatedArchive leaves a truncated archive in the
+// cache directory, as an interrupted download would, before installing the
+// same version.
+func TestInstallOverwritesTruncatedArchive(t *testing.T) {
+	const archiveName = "terraform_1.5.7_linux_amd64.zip"
+
+	mirrorDir := t.TempDir()
+	versionDir := filepath.Join(mirrorDir, "terraform", "1.5.7")
+
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	binary := []byte("#!/bin/sh\n")
+	writeTestArchive(t, filepath.Join(versionDir, archiveName), 0755, binary)
+
+	archive, err := ioutil.ReadFile(filepath.Join(versionDir, archiveName))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archiveHash := sha256.Sum256(archive)
+	checksums := fmt.Sprintf("%s  %s\n", hex.EncodeToString(archiveHash[:]), archiveName)
+
+	if err := ioutil.WriteFile(filepath.Join(versionDir, "terraform_1.5.7_SHA256SUMS"), []byte(checksums), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	index := `{"versions": {"1.5.7": {"shasums": "terraform_1.5.7_SHA256SUMS", "builds": [{"os": "linux", "arch": "amd64", "url": "` + archiveName + `"}]}}}`
+
+	if err := ioutil.WriteFile(filepath.Join(mirrorDir, "terraform", "index.json"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.FileServer(http.Dir(mirrorDir)))
+	t.Cleanup(server.Close)
+
+	baseURL, err := ParseBaseURL(server.URL + "/terraform/")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := t.TempDir()
+
+	if err := ioutil.WriteFile(filepath.Join(cacheDir, archiveName), archive[:len(archive)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(Options{
+		BaseURL:       baseURL,
+		DataDir:       t.TempDir(),
+		CacheDir:      cacheDir,
+		OS:            "linux",
+		Arch:          "amd64",
+		AllowInsecure: true,
+	})
+	m.index = jsonIndexReader{}
+
+	exact, err := version.NewConstraint("= 1.5.7")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := m.Install(context.Background(), exact)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := m.Verify(v); status != VerifyOK {
+		t.Errorf("installed 1.5.7 is %s", status)
+	}
+
+	installed, err := ioutil.ReadFile(m.BinaryPath(v))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(installed) != string(binary) {
+		t.Errorf("installed binary = %q, want %q", installed, binary)
+	}
+}
@@ -0,0 +1,114 @@
+package tvm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ErrNoInstalledVersion is returned by Resolve when none of the installed
+// versions satisfies the constraints.
+var ErrNoInstalledVersion = errors.New("None of the installed Terraform versions matched the constraints")
+
+// ListInstalled returns the installed versions, oldest first.
+func (m *Manager) ListInstalled() ([]*version.Version, error) {
+	tfVersionsDir, err := os.Open(m.VersionsDir())
+
+	if os.IsNotExist(err) {
+		return []*version.Version{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the names are read, Readdir would lstat every entry.
+	names, err := tfVersionsDir.Readdirnames(-1)
+
+	if err := tfVersionsDir.Close(); err != nil {
+		m.logger.Warnf("Error closing Terraform versions directory")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*version.Version, len(names))
+
+	for i, name := range names {
+		version, err := version.NewVersion(name)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid version directory %s: %s", path.Join(m.VersionsDir(), name), err)
+		}
+
+		versions[i] = version
+	}
+
+	sort.Sort(version.Collection(versions))
+
+	return versions, nil
+}
+
+// Match returns the installed versions satisfying the constraints, newest
+// first.
+func (m *Manager) Match(constraints version.Constraints) ([]*version.Version, error) {
+	versions, err := m.ListInstalled()
+
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*version.Version, 0)
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if Check(constraints, versions[i]) {
+			matches = append(matches, versions[i])
+		}
+	}
+
+	return matches, nil
+}
+
+// Resolve returns the newest installed version satisfying the constraints of
+// the Terraform configuration in dir, which is the version exec runs there.
+func (m *Manager) Resolve(dir string) (*version.Version, error) {
+	v, _, err := m.ResolveWithSource(dir)
+
+	return v, err
+}
+
+// ResolveWithSource is like Resolve but also describes what selected the
+// version: PinFileName, "required_version", or "newest installed version"
+// when the configuration has no constraints.
+func (m *Manager) ResolveWithSource(dir string) (*version.Version, string, error) {
+	constraints, pinned, err := m.constraintsAndPin(dir)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	matches, err := m.Match(constraints)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(matches) == 0 {
+		return nil, "", ErrNoInstalledVersion
+	}
+
+	source := "newest installed version"
+
+	if pinned {
+		source = PinFileName
+	} else if len(constraints) > 0 {
+		source = "required_version"
+	}
+
+	return matches[0], source, nil
+}
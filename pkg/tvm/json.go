@@ -0,0 +1,162 @@
+package tvm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/go-version"
+)
+
+// jsonIndexReader reads the index.json file of the releases index, listing
+// every version with its archives, SHA256SUMS file and signatures.
+type jsonIndexReader struct{}
+
+type jsonIndex struct {
+	Versions map[string]jsonIndexVersion `json:"versions"`
+}
+
+type jsonIndexVersion struct {
+	Shasums           string           `json:"shasums"`
+	ShasumsSignature  string           `json:"shasums_signature"`
+	ShasumsSignatures []string         `json:"shasums_signatures"`
+	Builds            []jsonIndexBuild `json:"builds"`
+}
+
+type jsonIndexBuild struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+	URL  string `json:"url"`
+}
+
+func (jsonIndexReader) readIndex(ctx context.Context, m *Manager) ([]Release, error) {
+	index, _, err := m.readJSONIndex(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, 0, len(index.Versions))
+
+	for rawVersion, entry := range index.Versions {
+		if release := m.jsonRelease(rawVersion, entry); release.URL != nil {
+			releases = append(releases, release)
+		}
+	}
+
+	return releases, nil
+}
+
+func (jsonIndexReader) checkIndex(ctx context.Context, m *Manager) ([]MirrorCheck, Release, *url.URL) {
+	index, indexURL, err := m.readJSONIndex(ctx)
+	checks := []MirrorCheck{{Name: "index", URL: indexURL, Err: err}}
+
+	if err != nil {
+		return checks, Release{}, nil
+	}
+
+	var newestRawVersion string
+	var newestVersion *version.Version
+
+	for rawVersion := range index.Versions {
+		v, err := version.NewVersion(rawVersion)
+
+		if err != nil || v.Prerelease() != "" {
+			continue
+		}
+
+		if newestVersion == nil || v.GreaterThan(newestVersion) {
+			newestRawVersion, newestVersion = rawVersion, v
+		}
+	}
+
+	if newestVersion == nil {
+		checks = append(checks, MirrorCheck{Name: "index", URL: indexURL, Err: errors.New("No version listed")})
+
+		return checks, Release{}, nil
+	}
+
+	release := m.jsonRelease(newestRawVersion, index.Versions[newestRawVersion])
+
+	if release.URL == nil {
+		checks = append(checks, MirrorCheck{Name: "archive", URL: indexURL, Err: fmt.Errorf("No archive of %s for %s listed", newestVersion, m.platform())})
+	}
+
+	return checks, release, indexURL
+}
+
+// readJSONIndex fetches and decodes the index.json file below the base URL,
+// whose URL it returns too.
+func (m *Manager) readJSONIndex(ctx context.Context) (jsonIndex, *url.URL, error) {
+	indexURL := m.opts.BaseURL.ResolveReference(&url.URL{Path: "index.json"})
+
+	data, err := m.fetch(ctx, indexURL)
+
+	if err != nil {
+		return jsonIndex{}, indexURL, err
+	}
+
+	index := jsonIndex{}
+
+	if err := json.Unmarshal(data, &index); err != nil {
+		return jsonIndex{}, indexURL, fmt.Errorf("Invalid index %s: %s", indexURL, err)
+	}
+
+	return index, indexURL, nil
+}
+
+// jsonRelease returns the release of the version of the index for the
+// platform of the Manager, whose URL is nil when it has no archive for it. The
+// file names of the version are relative to its directory below the base URL,
+// like the links of its HTML page.
+func (m *Manager) jsonRelease(rawVersion string, entry jsonIndexVersion) Release {
+	release := Release{}
+
+	v, err := version.NewVersion(rawVersion)
+
+	if err != nil {
+		return release
+	}
+
+	versionURL := m.opts.BaseURL.ResolveReference(&url.URL{Path: rawVersion + "/"})
+	resolve := func(ref string) *url.URL {
+		url, err := url.Parse(ref)
+
+		if err != nil {
+			return nil
+		}
+
+		return versionURL.ResolveReference(url)
+	}
+
+	for _, build := range entry.Builds {
+		if build.OS == m.opts.OS && build.Arch == m.opts.Arch {
+			release.Version = v
+			release.URL = resolve(build.URL)
+		}
+	}
+
+	if release.URL == nil {
+		return Release{}
+	}
+
+	if entry.Shasums != "" {
+		release.ChecksumURL = resolve(entry.Shasums)
+	}
+
+	signatures := entry.ShasumsSignatures
+
+	if len(signatures) == 0 && entry.ShasumsSignature != "" {
+		signatures = []string{entry.ShasumsSignature}
+	}
+
+	for _, signature := range signatures {
+		if url := resolve(signature); url != nil {
+			release.ChecksumSignatureURLs = append(release.ChecksumSignatureURLs, url)
+		}
+	}
+
+	return release
+}
@@ -0,0 +1,150 @@
+package tvm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testJSONIndex = `{
+  "name": "terraform",
+  "versions": {
+    "0.11.14": {
+      "version": "0.11.14",
+      "shasums": "terraform_0.11.14_SHA256SUMS",
+      "shasums_signature": "terraform_0.11.14_SHA256SUMS.sig",
+      "builds": [
+        {"os": "linux", "arch": "amd64", "url": "terraform_0.11.14_linux_amd64.zip"}
+      ]
+    },
+    "1.5.7": {
+      "version": "1.5.7",
+      "shasums": "terraform_1.5.7_SHA256SUMS",
+      "shasums_signatures": ["terraform_1.5.7_SHA256SUMS.72D7468F.sig", "terraform_1.5.7_SHA256SUMS.sig"],
+      "builds": [
+        {"os": "darwin", "arch": "arm64", "url": "https://downloads.example.com/terraform_1.5.7_darwin_arm64.zip"},
+        {"os": "linux", "arch": "amd64", "url": "https://downloads.example.com/terraform_1.5.7_linux_amd64.zip"}
+      ]
+    },
+    "1.6.0-beta1": {
+      "version": "1.6.0-beta1",
+      "shasums": "terraform_1.6.0-beta1_SHA256SUMS",
+      "builds": [
+        {"os": "darwin", "arch": "arm64", "url": "terraform_1.6.0-beta1_darwin_arm64.zip"}
+      ]
+    }
+  }
+}`
+
+func newJSONIndexManager(t *testing.T) *Manager {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/terraform/index.json" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(testJSONIndex))
+	}))
+	t.Cleanup(server.Close)
+
+	baseURL, err := ParseBaseURL(server.URL + "/terraform")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(Options{
+		BaseURL:       baseURL,
+		DataDir:       t.TempDir(),
+		CacheDir:      t.TempDir(),
+		OS:            "linux",
+		Arch:          "amd64",
+		AllowInsecure: true,
+	})
+	m.index = jsonIndexReader{}
+
+	return m
+}
+
+func TestJSONIndexReader(t *testing.T) {
+	m := newJSONIndexManager(t)
+
+	releases, err := m.ListRemote(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(releases) != 2 {
+		t.Fatalf("got %d releases, want the 2 with a linux_amd64 build", len(releases))
+	}
+
+	baseURL := m.opts.BaseURL.String()
+
+	tests := []struct {
+		release    Release
+		version    string
+		url        string
+		checksums  string
+		signatures []string
+	}{
+		{
+			releases[0],
+			"0.11.14",
+			baseURL + "0.11.14/terraform_0.11.14_linux_amd64.zip",
+			baseURL + "0.11.14/terraform_0.11.14_SHA256SUMS",
+			[]string{baseURL + "0.11.14/terraform_0.11.14_SHA256SUMS.sig"},
+		},
+		{
+			releases[1],
+			"1.5.7",
+			"https://downloads.example.com/terraform_1.5.7_linux_amd64.zip",
+			baseURL + "1.5.7/terraform_1.5.7_SHA256SUMS",
+			[]string{baseURL + "1.5.7/terraform_1.5.7_SHA256SUMS.72D7468F.sig", baseURL + "1.5.7/terraform_1.5.7_SHA256SUMS.sig"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := tt.release.Version.String(); got != tt.version {
+				t.Errorf("version = %s, want %s", got, tt.version)
+			}
+
+			if got := tt.release.URL.String(); got != tt.url {
+				t.Errorf("url = %s, want %s", got, tt.url)
+			}
+
+			if got := tt.release.ChecksumURL.String(); got != tt.checksums {
+				t.Errorf("checksum url = %s, want %s", got, tt.checksums)
+			}
+
+			if len(tt.release.ChecksumSignatureURLs) != len(tt.signatures) {
+				t.Fatalf("signature urls = %v, want %v", tt.release.ChecksumSignatureURLs, tt.signatures)
+			}
+
+			for i, url := range tt.release.ChecksumSignatureURLs {
+				if url.String() != tt.signatures[i] {
+					t.Errorf("signature url = %s, want %s", url, tt.signatures[i])
+				}
+			}
+		})
+	}
+}
+
+func TestJSONIndexReaderCheckIndex(t *testing.T) {
+	m := newJSONIndexManager(t)
+
+	checks, release, _ := m.index.checkIndex(context.Background(), m)
+
+	if err := checks[len(checks)-1].Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if release.Version.String() != "1.5.7" {
+		t.Errorf("newest stable release = %s, want 1.5.7", release.Version)
+	}
+}
@@ -0,0 +1,187 @@
+package tvm
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// DefaultBaseURL is the releases index used when Options.BaseURL is nil.
+const DefaultBaseURL = "https://releases.hashicorp.com/terraform/"
+
+// Options configures a Manager. Only DataDir and CacheDir are required, the
+// zero value of every other field selects the default behavior.
+type Options struct {
+	// BaseURL is the releases index, or a mirror of it, versions are
+	// installed from. It defaults to DefaultBaseURL.
+	BaseURL *url.URL
+
+	// DataDir holds the installed versions, in its versions subdirectory,
+	// and the trusted keyring.
+	DataDir string
+
+	// CacheDir holds downloaded archives and the index caches.
+	CacheDir string
+
+	// TrustedKeyringPath is the armored keyring the SHA256SUMS signatures
+	// are verified against. It defaults to trusted.asc in DataDir.
+	TrustedKeyringPath string
+
+	// AdvisoriesPath is a JSON list of Advisory replacing the one embedded
+	// in tvm when it exists. It defaults to advisories.json in DataDir.
+	AdvisoriesPath string
+
+	// OS and Arch select the platform of the releases. They default to the
+	// platform tvm runs on.
+	OS   string
+	Arch string
+
+	// AllowInsecure allows downloading over plaintext HTTP.
+	AllowInsecure bool
+
+	// InsecureSkipVerify disables the verification of the certificates of
+	// HTTPS servers, for internal mirrors with self-signed certificates.
+	// Anyone on the network path can then impersonate the mirror, leaving
+	// only the checksum and signature verification to detect tampering, so
+	// a warning is logged whenever a Manager with it downloads anything.
+	InsecureSkipVerify bool
+
+	// RecursiveConstraints adds the required_version of local child modules
+	// to the constraints of a directory.
+	RecursiveConstraints bool
+
+	// ProviderMinTerraform maps provider names, or source addresses such as
+	// "hashicorp/aws", to the minimum Terraform version they need. The
+	// constraints of a directory then include the greatest minimum of the
+	// providers in its required_providers. This is a heuristic relying on
+	// a mapping maintained by the user, as provider requirements are not
+	// published in a form tvm could query.
+	ProviderMinTerraform map[string]*version.Version
+
+	// IndexCacheTTL is how long the list of available versions is reused
+	// before the releases index is read again. Zero disables the cache.
+	IndexCacheTTL time.Duration
+
+	// AllowedVersions, when not empty, restricts Install to the versions
+	// satisfying at least one of its constraints. DeniedVersions refuses
+	// the versions satisfying any of its constraints. See CheckPolicy.
+	AllowedVersions []version.Constraints
+	DeniedVersions  []version.Constraints
+
+	// Force makes Install download and install versions which are already
+	// installed.
+	Force bool
+
+	// PostInstall, when set, is called after a version has been installed.
+	// If it fails, the installed version is removed again and Install
+	// returns its error.
+	PostInstall func(version *version.Version, binPath string) error
+
+	// Timing, when set, is called with the time spent in each phase of the
+	// work: "scrape", "download", "verify" and "extract".
+	Timing func(phase string, duration time.Duration)
+
+	// Logger receives the progress messages. They are discarded when it is
+	// nil.
+	Logger Logger
+}
+
+// Logger is implemented by the leveled loggers the Manager reports to.
+type Logger interface {
+	Debugf(format string, a ...interface{})
+	Infof(format string, a ...interface{})
+	Warnf(format string, a ...interface{})
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Debugf(format string, a ...interface{}) {}
+func (discardLogger) Infof(format string, a ...interface{})  {}
+func (discardLogger) Warnf(format string, a ...interface{})  {}
+
+// Manager lists, installs and resolves Terraform versions. Its methods
+// never exit the program, failures are returned as errors.
+type Manager struct {
+	opts   Options
+	logger Logger
+	index  indexReader
+
+	httpClient               *http.Client
+	insecureSkipVerifyClient *http.Client
+
+	insecureWarning           sync.Once
+	insecureSkipVerifyWarning sync.Once
+}
+
+// New returns a Manager configured with opts, filling in the defaults.
+func New(opts Options) *Manager {
+	if opts.BaseURL == nil {
+		opts.BaseURL, _ = ParseBaseURL(DefaultBaseURL)
+	}
+
+	if opts.TrustedKeyringPath == "" {
+		opts.TrustedKeyringPath = path.Join(opts.DataDir, "trusted.asc")
+	}
+
+	if opts.AdvisoriesPath == "" {
+		opts.AdvisoriesPath = path.Join(opts.DataDir, "advisories.json")
+	}
+
+	if opts.OS == "" {
+		opts.OS = runtime.GOOS
+	}
+
+	if opts.Arch == "" {
+		opts.Arch = runtime.GOARCH
+	}
+
+	logger := opts.Logger
+
+	if logger == nil {
+		logger = discardLogger{}
+	}
+
+	m := &Manager{
+		opts:   opts,
+		logger: logger,
+		index:  defaultIndexReader,
+	}
+
+	m.httpClient = m.newHTTPClient(http.DefaultTransport)
+	m.insecureSkipVerifyClient = m.newHTTPClient(insecureSkipVerifyTransport)
+
+	return m
+}
+
+// observe reports the time spent in phase since start.
+func (m *Manager) observe(phase string, start time.Time) {
+	duration := time.Since(start)
+
+	m.logger.Debugf("Spent %s in %s", duration, phase)
+
+	if m.opts.Timing != nil {
+		m.opts.Timing(phase, duration)
+	}
+}
+
+// VersionsDir returns the directory the versions are installed in, one
+// subdirectory per version.
+func (m *Manager) VersionsDir() string {
+	return path.Join(m.opts.DataDir, "versions")
+}
+
+// VersionDir returns the directory a version is installed in.
+func (m *Manager) VersionDir(version *version.Version) string {
+	return path.Join(m.VersionsDir(), version.String())
+}
+
+// BinaryPath returns the path of the Terraform binary of a version, whether
+// it is installed or not.
+func (m *Manager) BinaryPath(version *version.Version) string {
+	return path.Join(m.VersionDir(version), "terraform")
+}
@@ -0,0 +1,124 @@
+package tvm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+const metadataFileName = "metadata.json"
+
+// Metadata is recorded next to each installed binary so that the
+// installation can later be verified without network access.
+type Metadata struct {
+	Version       string    `json:"version"`
+	URL           string    `json:"url"`
+	ArchiveSHA256 string    `json:"archive_sha256"`
+	BinarySHA256  string    `json:"binary_sha256"`
+	InstalledAt   time.Time `json:"installed_at"`
+}
+
+// VerifyStatus is the outcome of verifying an installed version.
+type VerifyStatus string
+
+const (
+	// VerifyOK means the binary matches the recorded metadata.
+	VerifyOK VerifyStatus = "OK"
+	// VerifyCorrupt means the binary is missing or doesn't match the
+	// recorded metadata.
+	VerifyCorrupt VerifyStatus = "CORRUPT"
+	// VerifyUnknown means no metadata was recorded, as for versions
+	// installed by earlier releases of tvm.
+	VerifyUnknown VerifyStatus = "UNKNOWN"
+)
+
+func writeMetadata(tfVersionDirPath string, metadata Metadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(tfVersionDirPath, metadataFileName), data, 0644)
+}
+
+func readMetadata(tfVersionDirPath string) (*Metadata, error) {
+	data, err := ioutil.ReadFile(path.Join(tfVersionDirPath, metadataFileName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := Metadata{}
+
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %s", path.Join(tfVersionDirPath, metadataFileName), err)
+	}
+
+	return &metadata, nil
+}
+
+// Metadata returns the metadata recorded when a version was installed.
+func (m *Manager) Metadata(version *version.Version) (*Metadata, error) {
+	return readMetadata(m.VersionDir(version))
+}
+
+func (m *Manager) hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			m.logger.Warnf("Error closing %s", filePath)
+		}
+	}()
+
+	h := sha256.New()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify checks the installed binary of a version against the hash recorded
+// at install time.
+func (m *Manager) Verify(version *version.Version) VerifyStatus {
+	tfVersionDirPath := m.VersionDir(version)
+
+	metadata, err := readMetadata(tfVersionDirPath)
+
+	if err != nil {
+		return VerifyUnknown
+	}
+
+	binaryHash, err := m.hashFile(path.Join(tfVersionDirPath, "terraform"))
+
+	if err != nil || binaryHash != metadata.BinarySHA256 {
+		return VerifyCorrupt
+	}
+
+	return VerifyOK
+}
+
+// IsInstalled reports whether the binary of a version is present and, when
+// metadata was recorded, still matches it.
+func (m *Manager) IsInstalled(version *version.Version) bool {
+	if _, err := os.Stat(m.BinaryPath(version)); err != nil {
+		return false
+	}
+
+	return m.Verify(version) != VerifyCorrupt
+}
@@ -0,0 +1,76 @@
+package tvm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+)
+
+// MirrorCheck is the outcome of checking one part of the layout of the
+// releases index, as returned by CheckMirror.
+type MirrorCheck struct {
+	Name string
+	URL  *url.URL
+	Err  error
+}
+
+// CheckMirror checks that BaseURL serves the layout tvm expects from the
+// releases index: an index listing the versions, or linking to their pages
+// with the html build tag, and, for the newest stable version, the archive
+// for the platform, a SHA256SUMS file listing it and a signature of the
+// latter. Checking stops at the first part which is missing, as the next
+// ones are found from it.
+func (m *Manager) CheckMirror(ctx context.Context) []MirrorCheck {
+	checks, release, pageURL := m.index.checkIndex(ctx, m)
+
+	if checks[len(checks)-1].Err != nil {
+		return checks
+	}
+
+	check := func(name string, url *url.URL, err error) bool {
+		checks = append(checks, MirrorCheck{Name: name, URL: url, Err: err})
+
+		return err == nil
+	}
+
+	body, err := m.open(ctx, release.URL)
+
+	if err == nil {
+		err = body.Close()
+	}
+
+	if !check("archive", release.URL, err) {
+		return checks
+	}
+
+	if release.ChecksumURL == nil {
+		check("checksums", pageURL, errors.New("No SHA256SUMS file linked"))
+
+		return checks
+	}
+
+	checksums, err := m.fetch(ctx, release.ChecksumURL)
+
+	if err == nil && !bytes.Contains(checksums, []byte(path.Base(release.URL.Path))) {
+		err = fmt.Errorf("No checksum of %s listed", path.Base(release.URL.Path))
+	}
+
+	if !check("checksums", release.ChecksumURL, err) {
+		return checks
+	}
+
+	if len(release.ChecksumSignatureURLs) == 0 {
+		check("signature", pageURL, errors.New("No SHA256SUMS signature linked"))
+
+		return checks
+	}
+
+	_, err = m.fetch(ctx, release.ChecksumSignatureURLs[0])
+
+	check("signature", release.ChecksumSignatureURLs[0], err)
+
+	return checks
+}
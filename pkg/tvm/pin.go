@@ -0,0 +1,48 @@
+package tvm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// PinFileName is the file pinning the Terraform version of a directory.
+const PinFileName = ".terraform-version"
+
+// Pin pins version in dir by writing it to its .terraform-version file.
+func (m *Manager) Pin(dir string, version *version.Version) error {
+	return ioutil.WriteFile(filepath.Join(dir, PinFileName), []byte(version.String()+"\n"), 0644)
+}
+
+// pinnedConstraint returns the exact constraint pinned in the
+// .terraform-version file of dir, or nil if there is none. Values which are
+// not versions, such as the latest or min-required keywords of tfenv, are
+// ignored with a warning.
+func (m *Manager) pinnedConstraint(dir string) (version.Constraints, error) {
+	pinFilePath := filepath.Join(dir, PinFileName)
+
+	data, err := ioutil.ReadFile(pinFilePath)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	value := strings.TrimSpace(string(data))
+
+	pinned, err := version.NewVersion(value)
+
+	if err != nil {
+		m.logger.Warnf("Ignoring %q in %s, only exact versions are supported", value, pinFilePath)
+
+		return nil, nil
+	}
+
+	return version.NewConstraint("= " + pinned.String())
+}
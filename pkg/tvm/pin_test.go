@@ -0,0 +1,70 @@
+package tvm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+type recordingLogger struct {
+	discardLogger
+	warnings []string
+}
+
+func (l *recordingLogger) Warnf(format string, a ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, a...))
+}
+
+func TestPinnedConstraint(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		warns bool
+	}{
+		{"1.5.7\n", "= 1.5.7", false},
+		{"v0.11.14", "= 0.11.14", false},
+		{"latest\n", "", true},
+		{"min-required\n", "", true},
+		{"latest:^1.5\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			dir := t.TempDir()
+
+			if err := ioutil.WriteFile(filepath.Join(dir, PinFileName), []byte(tt.value), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			logger := &recordingLogger{}
+			m := New(Options{Logger: logger})
+
+			constraints, err := m.pinnedConstraint(dir)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := constraints.String(); got != tt.want {
+				t.Errorf("constraint = %q, want %q", got, tt.want)
+			}
+
+			if warned := len(logger.warnings) > 0; warned != tt.warns {
+				t.Errorf("warnings = %q, want a warning: %t", logger.warnings, tt.warns)
+			}
+		})
+	}
+}
+
+func TestPinnedConstraintWithoutPinFile(t *testing.T) {
+	constraints, err := New(Options{}).pinnedConstraint(t.TempDir())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if constraints != nil {
+		t.Errorf("constraint = %q, want none", constraints)
+	}
+}
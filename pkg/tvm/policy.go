@@ -0,0 +1,47 @@
+package tvm
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// PolicyError is returned when a version is refused by the allowed or denied
+// versions of the Options.
+type PolicyError struct {
+	Version *version.Version
+	Reason  string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("Terraform %s is not permitted by policy: %s", e.Version, e.Reason)
+}
+
+// CheckPolicy returns a *PolicyError if version isn't satisfied by any of the
+// allowed versions, when there are some, or is satisfied by one of the denied
+// versions.
+func (m *Manager) CheckPolicy(version *version.Version) error {
+	for _, denied := range m.opts.DeniedVersions {
+		if denied.Check(version) {
+			return &PolicyError{
+				Version: version,
+				Reason:  fmt.Sprintf("it matches the denied versions %q", denied.String()),
+			}
+		}
+	}
+
+	if len(m.opts.AllowedVersions) == 0 {
+		return nil
+	}
+
+	for _, allowed := range m.opts.AllowedVersions {
+		if Check(allowed, version) {
+			return nil
+		}
+	}
+
+	return &PolicyError{
+		Version: version,
+		Reason:  "it matches none of the allowed versions",
+	}
+}
@@ -0,0 +1,140 @@
+package tvm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// requiredProvider is a provider of the required_providers of a
+// configuration: its local name and, when given, its source address.
+type requiredProvider struct {
+	Name   string
+	Source string
+}
+
+// providerConstraints returns a lower bound on the Terraform version implied
+// by the providers the configuration in dir requires, the greatest of their
+// minimums in ProviderMinTerraform. This is only as good as that mapping,
+// which tvm doesn't maintain.
+func (m *Manager) providerConstraints(dir string) (version.Constraints, error) {
+	if len(m.opts.ProviderMinTerraform) == 0 {
+		return nil, nil
+	}
+
+	providers, err := requiredProviders(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var minimum *version.Version
+	var minimumProvider string
+
+	for _, provider := range providers {
+		for _, key := range []string{provider.Source, provider.Name} {
+			v, ok := m.opts.ProviderMinTerraform[key]
+
+			if key == "" || !ok {
+				continue
+			}
+
+			if minimum == nil || v.GreaterThan(minimum) {
+				minimum, minimumProvider = v, key
+			}
+
+			break
+		}
+	}
+
+	if minimum == nil {
+		return nil, nil
+	}
+
+	m.logger.Debugf("Provider %s requires Terraform %s or later", minimumProvider, minimum)
+
+	return version.NewConstraint(">= " + minimum.String())
+}
+
+// requiredProviders parses the required_providers of the terraform blocks of
+// the *.tf files in dir, in both the map of version strings and the map of
+// objects with a source forms.
+func requiredProviders(dir string) ([]requiredProvider, error) {
+	filePaths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]requiredProvider, 0)
+
+	for _, filePath := range filePaths {
+		data, err := ioutil.ReadFile(filePath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := hcl.ParseBytes(data)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse %s: %s", filePath, err)
+		}
+
+		root, ok := file.Node.(*ast.ObjectList)
+
+		if !ok {
+			continue
+		}
+
+		for _, block := range objectLists(root.Filter("terraform")) {
+			for _, requiredProviders := range objectLists(block.Filter("required_providers")) {
+				for _, item := range requiredProviders.Items {
+					if len(item.Keys) != 1 {
+						continue
+					}
+
+					provider := requiredProvider{Name: keyName(item.Keys[0])}
+
+					if object, ok := item.Val.(*ast.ObjectType); ok {
+						for _, attribute := range object.List.Filter("source").Items {
+							if literal, ok := attribute.Val.(*ast.LiteralType); ok {
+								provider.Source, _ = literal.Token.Value().(string)
+							}
+						}
+					}
+
+					providers = append(providers, provider)
+				}
+			}
+		}
+	}
+
+	return providers, nil
+}
+
+// objectLists returns the bodies of the blocks of list.
+func objectLists(list *ast.ObjectList) []*ast.ObjectList {
+	bodies := make([]*ast.ObjectList, 0, len(list.Items))
+
+	for _, item := range list.Items {
+		if object, ok := item.Val.(*ast.ObjectType); ok {
+			bodies = append(bodies, object.List)
+		}
+	}
+
+	return bodies
+}
+
+func keyName(key *ast.ObjectKey) string {
+	if name, ok := key.Token.Value().(string); ok {
+		return name
+	}
+
+	return strings.Trim(key.Token.Text, `"`)
+}
@@ -0,0 +1,132 @@
+package tvm
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Release is a Terraform version available for the platform of the
+// Manager, along with the URLs of its archive, SHA256SUMS file and
+// SHA256SUMS signatures.
+type Release struct {
+	Version               *version.Version
+	URL                   *url.URL
+	ChecksumURL           *url.URL
+	ChecksumSignatureURLs []*url.URL
+}
+
+// indexReader reads the releases index in one of the layouts it is served
+// in: its index.json file by default, or its HTML pages with the html build
+// tag.
+type indexReader interface {
+	// readIndex returns the releases available for the platform of m, in
+	// any order.
+	readIndex(ctx context.Context, m *Manager) ([]Release, error)
+
+	// checkIndex checks the index down to the newest stable release, for
+	// CheckMirror. It returns the checks made, the release and the URL
+	// describing it, which are only set when the last check passed.
+	checkIndex(ctx context.Context, m *Manager) ([]MirrorCheck, Release, *url.URL)
+}
+
+// defaultIndexReader is the indexReader of the Managers, replaced by the
+// HTML scraper when it is built in.
+var defaultIndexReader indexReader = jsonIndexReader{}
+
+// ListRemote returns the releases available from the releases index, oldest
+// first. The list is served from the index cache while it is fresh. When it
+// is stale, only one process refreshes it at a time, the others waiting for
+// it, until ctx is done, and then using the fresh cache.
+func (m *Manager) ListRemote(ctx context.Context) ([]Release, error) {
+	if releases, ok := m.cachedReleases(); ok {
+		return releases, nil
+	}
+
+	if m.opts.IndexCacheTTL == 0 {
+		return m.RefreshIndex(ctx)
+	}
+
+	var releases []Release
+
+	err := m.withFileLock(ctx, m.indexCachePath()+".lock", func() error {
+		// Another process may have refreshed the cache while this one was
+		// waiting for the lock.
+		if cached, ok := m.cachedReleases(); ok {
+			releases = cached
+
+			return nil
+		}
+
+		var err error
+
+		releases, err = m.RefreshIndex(ctx)
+
+		return err
+	})
+
+	return releases, err
+}
+
+func (m *Manager) cachedReleases() ([]Release, bool) {
+	releases, age, ok := m.readIndexCache()
+
+	if !ok {
+		return nil, false
+	}
+
+	m.logger.Debugf("Using index cache %s (%s old)", m.indexCachePath(), age)
+
+	sortReleases(releases)
+
+	return releases, true
+}
+
+// RefreshIndex reads the releases index, whatever the age of the index
+// cache, and saves the result to it. It returns the releases like
+// ListRemote.
+func (m *Manager) RefreshIndex(ctx context.Context) ([]Release, error) {
+	m.logger.Debugf("Fetching index from %s", m.opts.BaseURL)
+
+	start := time.Now()
+	releases, err := m.index.readIndex(ctx, m)
+	m.observe("scrape", start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.writeIndexCache(releases); err != nil {
+		m.logger.Warnf("Failed to write index cache: %s", err)
+	}
+
+	sortReleases(releases)
+
+	return releases, nil
+}
+
+func sortReleases(releases []Release) {
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version.LessThan(releases[j].Version)
+	})
+}
+
+func (m *Manager) fetch(ctx context.Context, url *url.URL) ([]byte, error) {
+	body, err := m.open(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := body.Close(); err != nil {
+			m.logger.Warnf("Error closing response body")
+		}
+	}()
+
+	return ioutil.ReadAll(body)
+}
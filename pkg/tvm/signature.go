@@ -0,0 +1,91 @@
+package tvm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// ErrSignatureVerification is returned when none of the signatures of a
+// SHA256SUMS file was made by a trusted key.
+var ErrSignatureVerification = errors.New("Signature verification failed")
+
+// isChecksumSignature reports whether url points to a detached signature of
+// a SHA256SUMS file, either plain (terraform_X_SHA256SUMS.sig) or suffixed
+// with the ID of the signing key (terraform_X_SHA256SUMS.72D7468F.sig).
+func isChecksumSignature(url *url.URL) bool {
+	name := path.Base(url.Path)
+
+	return strings.Contains(name, "_SHA256SUMS") && strings.HasSuffix(name, ".sig")
+}
+
+func (m *Manager) loadTrustedKeyring() (openpgp.EntityList, error) {
+	keyringFile, err := os.Open(m.opts.TrustedKeyringPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := keyringFile.Close(); err != nil {
+			m.logger.Warnf("Error closing trusted keyring")
+		}
+	}()
+
+	return openpgp.ReadArmoredKeyRing(keyringFile)
+}
+
+// verifyChecksumSignatures checks checksums against each of the signatures in
+// turn and succeeds as soon as one of them was made by a trusted key. Once a
+// trusted keyring exists, it fails when there is no such signature, including
+// when none could be found or fetched.
+func (m *Manager) verifyChecksumSignatures(ctx context.Context, checksums []byte, signatureURLs []*url.URL) error {
+	keyring, err := m.loadTrustedKeyring()
+
+	if os.IsNotExist(err) {
+		m.logger.Infof("No trusted keyring found at %s, skipping signature verification", m.opts.TrustedKeyringPath)
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("Error loading trusted keyring: %s", err)
+	}
+
+	if len(signatureURLs) == 0 {
+		m.logger.Warnf("No signature found")
+
+		return ErrSignatureVerification
+	}
+
+	for _, signatureURL := range signatureURLs {
+		signature, err := m.fetch(ctx, signatureURL)
+
+		if err != nil {
+			m.logger.Warnf("%s", err)
+
+			continue
+		}
+
+		signer, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(checksums), bytes.NewReader(signature))
+
+		if err != nil {
+			m.logger.Warnf("Signature %s could not be verified: %s", path.Base(signatureURL.Path), err)
+
+			continue
+		}
+
+		m.logger.Infof("Signature %s verified with key %s", path.Base(signatureURL.Path), signer.PrimaryKey.KeyIdString())
+
+		return nil
+	}
+
+	return ErrSignatureVerification
+}
@@ -0,0 +1,118 @@
+package tvm
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+// The fixtures in testdata/signatures sign terraform_1.5.7_SHA256SUMS with
+// the key of trusted.asc, 1D4E55A0: the .sig by gpg, with the issuer
+// fingerprint and key ID, and the .1D4E55A0.sig with the key ID only. The
+// .E4FA1241.sig is made by a key which isn't trusted.
+const signaturesTestdata = "testdata/signatures"
+
+func TestIsChecksumSignature(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"terraform_1.5.7_SHA256SUMS.sig", true},
+		{"terraform_1.5.7_SHA256SUMS.72D7468F.sig", true},
+		{"terraform_1.5.7_SHA256SUMS", false},
+		{"terraform_1.5.7_linux_amd64.zip", false},
+		{"terraform_1.5.7_linux_amd64.zip.sig", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := &url.URL{Path: "/terraform/1.5.7/" + tt.name}
+
+			if got := isChecksumSignature(url); got != tt.want {
+				t.Errorf("isChecksumSignature(%s) = %t, want %t", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksumSignatures(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir(signaturesTestdata)))
+	t.Cleanup(server.Close)
+
+	checksums, err := ioutil.ReadFile(filepath.Join(signaturesTestdata, "terraform_1.5.7_SHA256SUMS"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		signatures []string
+		keyring    string
+		wantErr    bool
+	}{
+		{"plain", []string{"terraform_1.5.7_SHA256SUMS.sig"}, "trusted.asc", false},
+		{"key ID suffixed only", []string{"terraform_1.5.7_SHA256SUMS.1D4E55A0.sig"}, "trusted.asc", false},
+		{"untrusted then trusted", []string{"terraform_1.5.7_SHA256SUMS.E4FA1241.sig", "terraform_1.5.7_SHA256SUMS.1D4E55A0.sig"}, "trusted.asc", false},
+		{"missing then trusted", []string{"terraform_1.5.7_SHA256SUMS.72D7468F.sig", "terraform_1.5.7_SHA256SUMS.sig"}, "trusted.asc", false},
+		{"untrusted only", []string{"terraform_1.5.7_SHA256SUMS.E4FA1241.sig"}, "trusted.asc", true},
+		{"missing only", []string{"terraform_1.5.7_SHA256SUMS.72D7468F.sig"}, "trusted.asc", true},
+		{"none", nil, "trusted.asc", true},
+		{"none without keyring", nil, "missing.asc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(Options{
+				TrustedKeyringPath: filepath.Join(signaturesTestdata, tt.keyring),
+				AllowInsecure:      true,
+			})
+
+			urls := make([]*url.URL, 0, len(tt.signatures))
+
+			for _, signature := range tt.signatures {
+				signatureURL, err := url.Parse(server.URL + "/" + signature)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				urls = append(urls, signatureURL)
+			}
+
+			err := m.verifyChecksumSignatures(context.Background(), checksums, urls)
+
+			if tt.wantErr && err != ErrSignatureVerification {
+				t.Errorf("error = %v, want %v", err, ErrSignatureVerification)
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("error = %v, want none", err)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksumSignaturesTampered(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir(signaturesTestdata)))
+	t.Cleanup(server.Close)
+
+	signatureURL, err := url.Parse(server.URL + "/terraform_1.5.7_SHA256SUMS.sig")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(Options{
+		TrustedKeyringPath: filepath.Join(signaturesTestdata, "trusted.asc"),
+		AllowInsecure:      true,
+	})
+
+	if err := m.verifyChecksumSignatures(context.Background(), []byte("tampered\n"), []*url.URL{signatureURL}); err != ErrSignatureVerification {
+		t.Errorf("error = %v, want %v", err, ErrSignatureVerification)
+	}
+}
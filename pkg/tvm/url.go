@@ -0,0 +1,52 @@
+package tvm
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseBaseURL parses the URL of a releases index and makes sure its path
+// ends with a slash so that relative links resolve beneath it.
+func ParseBaseURL(rawURL string) (*url.URL, error) {
+	url, err := url.Parse(rawURL)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid base URL %s: %s", rawURL, err)
+	}
+
+	if url.Host == "" {
+		return nil, fmt.Errorf("Invalid base URL %s: missing host", rawURL)
+	}
+
+	if !strings.HasSuffix(url.Path, "/") {
+		url.Path += "/"
+	}
+
+	return url, nil
+}
+
+// checkURLScheme refuses plaintext HTTP, unless AllowInsecure is set in
+// which case it is accepted with a warning, and schemes without a fetcher.
+func (m *Manager) checkURLScheme(url *url.URL) error {
+	switch url.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if !m.opts.AllowInsecure {
+			return fmt.Errorf("Refusing to download %s over plaintext HTTP, use --allow-insecure to allow it", url)
+		}
+
+		m.insecureWarning.Do(func() {
+			m.logger.Warnf("Downloading over plaintext HTTP, the integrity of the downloads relies on checksum and signature verification only")
+		})
+
+		return nil
+	}
+
+	if _, ok := fetchers[url.Scheme]; !ok {
+		return fmt.Errorf("Unsupported URL scheme %q in %s", url.Scheme, url)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// startProfiling writes a CPU profile and an execution trace of tvm to the
+// given files, when not empty, until the returned function is called. exec
+// calls it before handing over to Terraform, which replaces the process.
+func startProfiling(cpuProfilePath string, tracePath string) (func(), error) {
+	files := make([]*os.File, 0, 2)
+	stops := make([]func(), 0, 2)
+
+	stop := func() {
+		for _, stop := range stops {
+			stop()
+		}
+
+		for _, f := range files {
+			if err := f.Close(); err != nil {
+				warnf("Error closing %s", f.Name())
+			}
+		}
+
+		stops, files = nil, nil
+	}
+
+	if cpuProfilePath != "" {
+		f, err := os.Create(cpuProfilePath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+
+		if err := pprof.StartCPUProfile(f); err != nil {
+			stop()
+
+			return nil, fmt.Errorf("Failed to start CPU profile: %s", err)
+		}
+
+		stops = append(stops, pprof.StopCPUProfile)
+	}
+
+	if tracePath != "" {
+		f, err := os.Create(tracePath)
+
+		if err != nil {
+			stop()
+
+			return nil, err
+		}
+
+		files = append(files, f)
+
+		if err := trace.Start(f); err != nil {
+			stop()
+
+			return nil, fmt.Errorf("Failed to start trace: %s", err)
+		}
+
+		stops = append(stops, trace.Stop)
+	}
+
+	return stop, nil
+}
+
+// hideFlags leaves the named flags, meant for troubleshooting only, out of
+// the usage message of flagSet.
+func hideFlags(flagSet *flag.FlagSet, names ...string) {
+	hidden := map[string]bool{}
+
+	for _, name := range names {
+		hidden[name] = true
+	}
+
+	flagSet.Usage = func() {
+		visible := flag.NewFlagSet(flagSet.Name(), flag.ContinueOnError)
+		visible.SetOutput(flagSet.Output())
+
+		flagSet.VisitAll(func(f *flag.Flag) {
+			if !hidden[f.Name] {
+				visible.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+
+		fmt.Fprintf(flagSet.Output(), "Usage of %s:\n", flagSet.Name())
+		visible.PrintDefaults()
+	}
+}
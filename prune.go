@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hashicorp/go-version"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+type pruneOptions struct {
+	DryRun      bool
+	Reinstall   bool
+	StrictHooks bool
+}
+
+// pruneFailed removes, or reinstalls, the installed versions whose Terraform
+// binary is missing, as left behind by interrupted or failed installs.
+func pruneFailed(opts pruneOptions) error {
+	m := tvm.New(installManagerOptions(true, opts.StrictHooks))
+
+	versions, err := m.ListInstalled()
+
+	if err != nil {
+		return err
+	}
+
+	pruned := 0
+
+	for _, v := range versions {
+		if _, err := os.Stat(m.BinaryPath(v)); !os.IsNotExist(err) {
+			continue
+		}
+
+		pruned++
+
+		if opts.DryRun {
+			if opts.Reinstall {
+				fmt.Printf("Would reinstall incomplete Terraform version %s\n", v)
+			} else {
+				fmt.Printf("Would remove incomplete Terraform version %s\n", v)
+			}
+
+			continue
+		}
+
+		if err := os.RemoveAll(m.VersionDir(v)); err != nil {
+			return err
+		}
+
+		if !opts.Reinstall {
+			fmt.Printf("Removed incomplete Terraform version %s\n", v)
+
+			continue
+		}
+
+		exact, err := version.NewConstraint("= " + v.String())
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if _, err := m.Install(context.Background(), exact); err != nil {
+			return fmt.Errorf("Failed to reinstall Terraform version %s: %s", v, err)
+		}
+	}
+
+	if pruned == 0 {
+		fmt.Println("No incomplete install found")
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+// reinstall installs the newest version satisfying constraint afresh,
+// replacing the existing installation only once the new one succeeded.
+func reinstall(constraint string, strictHooks bool) error {
+	constraints, err := version.NewConstraint(constraint)
+
+	if err != nil {
+		return fmt.Errorf("Invalid version or constraint %q: %s", constraint, err)
+	}
+
+	m := tvm.New(installManagerOptions(true, strictHooks))
+
+	tfVersion, replaced, err := m.Reinstall(context.Background(), constraints)
+
+	if err != nil {
+		return err
+	}
+
+	if replaced {
+		fmt.Printf("Removed the previous installation of Terraform version %s\n", tfVersion)
+	}
+
+	warnAdvisories(m, tfVersion)
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// lockErrorMessage is what Terraform prints when it fails to acquire the
+// state lock because another run holds it.
+const lockErrorMessage = "Error acquiring the state lock"
+
+// isRetryableCommand reports whether the Terraform subcommand in args is one
+// of the commands the user allowed to be retried on lock errors.
+func isRetryableCommand(args []string, retryableCommands []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		for _, retryableCommand := range retryableCommands {
+			if arg == retryableCommand {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return false
+}
+
+// runTerraformRetryingOnLock runs Terraform and, as long as it fails because
+// the state lock is held, runs it again up to retries more times, doubling
+// the delay between attempts each time.
+//
+// Retrying is only safe for commands which do not change anything when they
+// fail half-way, which is why it is off by default and limited to the
+// commands given with --retry-lock-commands. Terraform's own -lock-timeout
+// should be preferred when the command supports it.
+func runTerraformRetryingOnLock(binPath string, args []string, env []string, opts execOptions) int {
+	backoff := opts.RetryLockBackoff
+
+	for attempt := 0; ; attempt++ {
+		stderr := bytes.Buffer{}
+
+		exitCode, err := runTerraform(binPath, args, env, io.MultiWriter(os.Stderr, &stderr), opts.Timeout)
+
+		if err != nil {
+			fmt.Println(err)
+
+			return 1
+		}
+
+		if exitCode == 0 || attempt >= opts.RetryOnLock || !bytes.Contains(stderr.Bytes(), []byte(lockErrorMessage)) {
+			return exitCode
+		}
+
+		warnf("State lock is held, retrying in %s (%d/%d)", backoff, attempt+1, opts.RetryOnLock)
+
+		time.Sleep(backoff)
+
+		backoff *= 2
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+type runOptions struct {
+	StrictHooks bool
+	Timeout     time.Duration
+}
+
+// run runs Terraform with args using the newest installed version satisfying
+// constraint, installing the newest available one if there is none. Unlike
+// exec, the constraints of the project are ignored and nothing is pinned.
+func run(constraint string, args []string, opts runOptions) {
+	constraints, err := version.NewConstraint(constraint)
+
+	if err != nil {
+		fmt.Printf("Invalid version or constraint %q: %s\n", constraint, err)
+		os.Exit(1)
+	}
+
+	infoOutput = os.Stderr
+
+	m := tvm.New(installManagerOptions(false, opts.StrictHooks))
+
+	matches, err := m.Match(constraints)
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var tfVersion *version.Version
+
+	if len(matches) > 0 {
+		tfVersion = matches[0]
+
+		if err := m.CheckPolicy(tfVersion); err != nil {
+			if cfg.BlockDeniedExec {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			warnf("%s", err)
+		}
+	} else if tfVersion, err = m.Install(context.Background(), constraints); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	warnAdvisories(m, tfVersion)
+	audit(tfVersion, "tvm run "+constraint, args)
+
+	handOff(m.BinaryPath(tfVersion), args, execOptions{Timeout: opts.Timeout})
+}
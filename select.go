@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+type selectOptions struct {
+	Prerelease  bool
+	StrictHooks bool
+}
+
+type selectItem struct {
+	Version   *version.Version
+	Installed bool
+	Matches   bool
+}
+
+func (item selectItem) String() string {
+	labels := make([]string, 0, 2)
+
+	if item.Installed {
+		labels = append(labels, "installed")
+	}
+
+	if item.Matches {
+		labels = append(labels, "satisfies constraints")
+	}
+
+	if len(labels) == 0 {
+		return item.Version.String()
+	}
+
+	return fmt.Sprintf("%-12s (%s)", item.Version, strings.Join(labels, ", "))
+}
+
+// selectVersion lets the user pick one of the available versions, newest
+// first, then installs it and pins it in the current directory. It uses a
+// full screen list on terminals and a numbered prompt otherwise.
+func selectVersion(opts selectOptions) {
+	m := tvm.New(installManagerOptions(false, opts.StrictHooks))
+	dir := workingDir()
+
+	releases, err := m.ListRemote(context.Background())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	installed, err := m.ListInstalled()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	constraints, err := m.Constraints(dir)
+
+	if err != nil {
+		warnf("Not checking constraints: %s", err)
+	}
+
+	versions := make([]*version.Version, len(releases))
+
+	for i, release := range releases {
+		versions[i] = release.Version
+	}
+
+	versions = selectVersions(versions, listOptions{Desc: true, Prerelease: opts.Prerelease})
+
+	items := make([]selectItem, len(versions))
+
+	for i, v := range versions {
+		items[i] = selectItem{Version: v, Matches: constraints != nil && tvm.Check(constraints, v)}
+
+		for _, installedVersion := range installed {
+			if installedVersion.Equal(v) {
+				items[i].Installed = true
+			}
+		}
+	}
+
+	if len(items) == 0 {
+		fmt.Println("No Terraform version available")
+		os.Exit(1)
+	}
+
+	var choice int
+
+	if terminal.IsTerminal(int(os.Stdin.Fd())) && terminal.IsTerminal(int(os.Stdout.Fd())) {
+		choice, err = selectInteractively(items)
+	} else {
+		choice, err = selectByNumber(items)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if choice < 0 {
+		fmt.Println("No version selected")
+
+		return
+	}
+
+	selected := items[choice].Version
+
+	exact, err := version.NewConstraint("= " + selected.String())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := m.Install(context.Background(), exact); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := m.Pin(dir, selected); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Pinned Terraform version %s\n", selected)
+}
+
+// selectByNumber prints the numbered list of items and reads the number, or
+// the version, of the chosen one. It returns -1 on an empty answer.
+func selectByNumber(items []selectItem) (int, error) {
+	for i, item := range items {
+		fmt.Printf("%4d) %s\n", i+1, item)
+	}
+
+	fmt.Printf("Select a version: ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	if err != nil && answer == "" {
+		return -1, nil
+	}
+
+	answer = strings.TrimSpace(answer)
+
+	if answer == "" {
+		return -1, nil
+	}
+
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(items) {
+		return n - 1, nil
+	}
+
+	for i, item := range items {
+		if item.Version.String() == answer {
+			return i, nil
+		}
+	}
+
+	return -1, fmt.Errorf("Invalid selection %q", answer)
+}
+
+// selectInteractively shows a scrollable list of items on the terminal,
+// moved through with the arrow, j/k and page keys. Enter picks the
+// highlighted item, q, escape or ^C cancel and return -1.
+func selectInteractively(items []selectItem) (int, error) {
+	fd := int(os.Stdin.Fd())
+
+	state, err := terminal.MakeRaw(fd)
+
+	if err != nil {
+		return -1, err
+	}
+
+	defer func() {
+		if err := terminal.Restore(fd, state); err != nil {
+			fmt.Println("Error restoring terminal")
+		}
+
+		fmt.Print("\x1b[?25h")
+	}()
+
+	height := 20
+
+	if _, rows, err := terminal.GetSize(fd); err == nil && rows > 3 {
+		height = rows - 2
+	}
+
+	cursor, offset := 0, 0
+	input := bufio.NewReader(os.Stdin)
+
+	for {
+		if cursor < offset {
+			offset = cursor
+		} else if cursor >= offset+height {
+			offset = cursor - height + 1
+		}
+
+		screen := strings.Builder{}
+		screen.WriteString("\x1b[?25l\x1b[H\x1b[2J")
+		screen.WriteString("Select a Terraform version (enter to install and pin, q to cancel)\r\n")
+
+		for i := offset; i < len(items) && i < offset+height; i++ {
+			if i == cursor {
+				screen.WriteString("\x1b[7m> " + items[i].String() + "\x1b[0m\r\n")
+			} else {
+				screen.WriteString("  " + items[i].String() + "\r\n")
+			}
+		}
+
+		fmt.Print(screen.String())
+
+		key, err := readKey(input)
+
+		if err != nil {
+			return -1, err
+		}
+
+		switch key {
+		case "up", "k":
+			if cursor > 0 {
+				cursor--
+			}
+		case "down", "j":
+			if cursor < len(items)-1 {
+				cursor++
+			}
+		case "pgup":
+			cursor -= height
+
+			if cursor < 0 {
+				cursor = 0
+			}
+		case "pgdown":
+			cursor += height
+
+			if cursor > len(items)-1 {
+				cursor = len(items) - 1
+			}
+		case "enter":
+			fmt.Print("\x1b[H\x1b[2J")
+
+			return cursor, nil
+		case "q", "esc", "ctrl-c":
+			fmt.Print("\x1b[H\x1b[2J")
+
+			return -1, nil
+		}
+	}
+}
+
+func readKey(input *bufio.Reader) (string, error) {
+	b, err := input.ReadByte()
+
+	if err != nil {
+		return "", err
+	}
+
+	switch b {
+	case '\r', '\n':
+		return "enter", nil
+	case 3:
+		return "ctrl-c", nil
+	case 0x1b:
+		if input.Buffered() == 0 {
+			return "esc", nil
+		}
+
+		sequence := make([]byte, 0, 3)
+
+		for input.Buffered() > 0 && len(sequence) < 3 {
+			b, err := input.ReadByte()
+
+			if err != nil {
+				return "", err
+			}
+
+			sequence = append(sequence, b)
+		}
+
+		switch string(sequence) {
+		case "[A", "OA":
+			return "up", nil
+		case "[B", "OB":
+			return "down", nil
+		case "[5~":
+			return "pgup", nil
+		case "[6~":
+			return "pgdown", nil
+		}
+
+		return "", nil
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// localStatePath returns the path of the local state of the current
+// workspace of the configuration in dir, as the local backend stores it.
+func localStatePath(dir string) string {
+	workspace := os.Getenv("TF_WORKSPACE")
+
+	if workspace == "" {
+		if data, err := ioutil.ReadFile(filepath.Join(dir, ".terraform", "environment")); err == nil {
+			workspace = strings.TrimSpace(string(data))
+		}
+	}
+
+	if workspace == "" || workspace == "default" {
+		return filepath.Join(dir, "terraform.tfstate")
+	}
+
+	return filepath.Join(dir, "terraform.tfstate.d", workspace, "terraform.tfstate")
+}
+
+// stateTerraformVersion returns the terraform_version recorded in the state
+// file at statePath, or nil if there is no such file. Only the top level
+// keys preceding it are read, it comes second in the files Terraform writes.
+func stateTerraformVersion(statePath string) (*version.Version, error) {
+	stateFile, err := os.Open(statePath)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := stateFile.Close(); err != nil {
+			warnf("Error closing state file")
+		}
+	}()
+
+	decoder := json.NewDecoder(stateFile)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("Invalid state file %s", statePath)
+	}
+
+	for decoder.More() {
+		key, err := decoder.Token()
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid state file %s: %s", statePath, err)
+		}
+
+		if key != "terraform_version" {
+			if err := decoder.Decode(&json.RawMessage{}); err != nil {
+				return nil, fmt.Errorf("Invalid state file %s: %s", statePath, err)
+			}
+
+			continue
+		}
+
+		rawVersion := ""
+
+		if err := decoder.Decode(&rawVersion); err != nil {
+			return nil, fmt.Errorf("Invalid terraform_version in %s: %s", statePath, err)
+		}
+
+		return version.NewVersion(rawVersion)
+	}
+
+	return nil, nil
+}
+
+// releaseLine returns the release line of v, within which state files are
+// compatible: the major version from 1.0 on, the minor one before.
+func releaseLine(v *version.Version) string {
+	segments := v.Segments()
+
+	if segments[0] >= 1 {
+		return fmt.Sprintf("%d.x", segments[0])
+	}
+
+	return fmt.Sprintf("%d.%d", segments[0], segments[1])
+}
+
+// checkState compares tfVersion with the version which last wrote the local
+// state in dir and returns a description of the mismatch when they are from
+// different release lines, as running it could upgrade the state for good or
+// fail to read it.
+func checkState(dir string, tfVersion *version.Version) (string, error) {
+	statePath := localStatePath(dir)
+
+	stateVersion, err := stateTerraformVersion(statePath)
+
+	if err != nil || stateVersion == nil {
+		return "", err
+	}
+
+	if releaseLine(stateVersion) == releaseLine(tfVersion) {
+		return "", nil
+	}
+
+	what := "may upgrade it irreversibly"
+
+	if tfVersion.LessThan(stateVersion) {
+		what = "may fail to read it"
+	}
+
+	return fmt.Sprintf("State check: %s was written by Terraform %s, running Terraform %s %s", statePath, stateVersion, tfVersion, what), nil
+}
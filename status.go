@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"runtime/debug"
+)
+
+// tvmVersion is the version of tvm, set at build time with
+// -ldflags "-X main.tvmVersion=...". When it isn't, the version of the
+// module from the build information is used.
+var tvmVersion string
+
+type statusOptions struct {
+	JSON bool
+}
+
+// dirStatus is a directory of tvm and the size of its content.
+type dirStatus struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// statusReport is what status prints, errors being reported in place of
+// the values that couldn't be found out.
+type statusReport struct {
+	SchemaVersion     int       `json:"schema_version"`
+	Version           string    `json:"version"`
+	DataDir           dirStatus `json:"data_dir"`
+	CacheDir          dirStatus `json:"cache_dir"`
+	InstalledVersions int       `json:"installed_versions"`
+	WorkingDir        string    `json:"working_dir"`
+	Constraints       string    `json:"constraints,omitempty"`
+	SelectedVersion   string    `json:"selected_version,omitempty"`
+	SelectedFrom      string    `json:"selected_from,omitempty"`
+	SelectionError    string    `json:"selection_error,omitempty"`
+	IndexCacheAge     string    `json:"index_cache_age,omitempty"`
+	TerraformInPath   string    `json:"terraform_in_path,omitempty"`
+	Shim              bool      `json:"shim"`
+}
+
+// status prints an overview of tvm and of what it selects in the current
+// directory, the first thing to look at when something is off.
+func status(opts statusOptions) {
+	m := newManager()
+	dir := workingDir()
+
+	report := statusReport{
+		SchemaVersion: jsonSchemaVersion,
+		Version:       currentTVMVersion(),
+		DataDir:       dirStatus{Path: dataDirPath, Size: dirSize(dataDirPath)},
+		CacheDir:      dirStatus{Path: cacheDirPath, Size: dirSize(cacheDirPath)},
+		WorkingDir:    dir,
+	}
+
+	if versions, err := m.ListInstalled(); err == nil {
+		report.InstalledVersions = len(versions)
+	}
+
+	if constraints, err := m.Constraints(dir); err != nil {
+		report.SelectionError = err.Error()
+	} else {
+		report.Constraints = constraints.String()
+
+		if v, source, err := m.ResolveWithSource(dir); err != nil {
+			report.SelectionError = err.Error()
+		} else {
+			report.SelectedVersion = v.String()
+			report.SelectedFrom = source
+		}
+	}
+
+	if age, ok := m.IndexCacheAge(); ok {
+		report.IndexCacheAge = age.String()
+	}
+
+	report.TerraformInPath, report.Shim = terraformInPath()
+
+	if opts.JSON {
+		printJSON(report)
+
+		return
+	}
+
+	fmt.Printf("%-20s %s\n", "tvm version:", report.Version)
+	fmt.Printf("%-20s %s (%s)\n", "Data directory:", report.DataDir.Path, formatSize(report.DataDir.Size))
+	fmt.Printf("%-20s %s (%s)\n", "Cache directory:", report.CacheDir.Path, formatSize(report.CacheDir.Size))
+	fmt.Printf("%-20s %d\n", "Installed versions:", report.InstalledVersions)
+	fmt.Printf("%-20s %s\n", "Directory:", report.WorkingDir)
+
+	if report.SelectionError != "" {
+		fmt.Printf("%-20s %s\n", "Selected version:", report.SelectionError)
+	} else {
+		constraints := report.Constraints
+
+		if constraints == "" {
+			constraints = "none"
+		}
+
+		fmt.Printf("%-20s %s\n", "Constraints:", constraints)
+		fmt.Printf("%-20s %s (from %s)\n", "Selected version:", report.SelectedVersion, report.SelectedFrom)
+	}
+
+	if report.IndexCacheAge != "" {
+		fmt.Printf("%-20s %s old\n", "Index cache:", report.IndexCacheAge)
+	} else {
+		fmt.Printf("%-20s none\n", "Index cache:")
+	}
+
+	switch {
+	case report.TerraformInPath == "":
+		fmt.Printf("%-20s none\n", "terraform in PATH:")
+	case report.Shim:
+		fmt.Printf("%-20s %s (the tvm shim)\n", "terraform in PATH:", report.TerraformInPath)
+	default:
+		fmt.Printf("%-20s %s (not the tvm shim)\n", "terraform in PATH:", report.TerraformInPath)
+	}
+}
+
+func currentTVMVersion() string {
+	if tvmVersion != "" {
+		return tvmVersion
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+
+	return "unknown"
+}
+
+// terraformInPath returns the terraform found in PATH and whether it is tvm
+// itself, used as a shim.
+func terraformInPath() (string, bool) {
+	binPath, err := osexec.LookPath("terraform")
+
+	if err != nil {
+		return "", false
+	}
+
+	self, err := os.Executable()
+
+	if err != nil {
+		return binPath, false
+	}
+
+	selfInfo, err := os.Stat(self)
+
+	if err != nil {
+		return binPath, false
+	}
+
+	info, err := os.Stat(binPath)
+
+	return binPath, err == nil && os.SameFile(info, selfInfo)
+}
+
+// dirSize returns the total size of the regular files beneath dir, ignoring
+// those which can't be read.
+func dirSize(dir string) int64 {
+	var size int64
+
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
+
+func formatSize(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	value := float64(size)
+	unit := 0
+
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// commandSummaries are the one-line descriptions of the commands in the
+// usage message.
+var commandSummaries = map[string]string{
+	"list":           "List available or installed Terraform versions",
+	"install":        "Install the newest version satisfying the constraints of the current directory",
+	"reinstall":      "Install a version afresh, replacing the existing installation",
+	"exec":           "Run the Terraform version selected for the current directory",
+	"run":            "Run a given Terraform version, installing it if needed",
+	"which":          "Print the path of the Terraform binary exec would run",
+	"installed-path": "Print the path of the binary of an installed version",
+	"select":         "Choose a version to install and pin in .terraform-version",
+	"diff":           "Link the changelogs between two versions",
+	"bundle":         "Write versions to an archive for installing offline",
+	"gc":             "Remove old cache files and installed versions",
+	"prune-failed":   "Remove or reinstall incomplete installs",
+	"status":         "Summarize the setup of tvm and what it selects here",
+	"doctor":         "Check the setup of tvm",
+}
+
+func printUsage(w io.Writer, commands []*flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: tvm <command> [options] [arguments]\n\nCommands:\n")
+
+	for _, command := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", command.Name(), commandSummaries[command.Name()])
+	}
+
+	fmt.Fprintf(w, "\nRun tvm help <command> for the options of a command.\n")
+}
+
+// help prints the usage message or, given the name of a command, its
+// options, exiting with a non-zero status for an unknown command.
+func help(commands []*flag.FlagSet, args []string) {
+	if len(args) == 0 {
+		printUsage(os.Stdout, commands)
+
+		return
+	}
+
+	for _, command := range commands {
+		if command.Name() == args[0] {
+			command.SetOutput(os.Stdout)
+			command.Usage()
+
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+	printUsage(os.Stderr, commands)
+	os.Exit(1)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/yann-soubeyrand/tvm/pkg/tvm"
+)
+
+// minPollInterval bounds how often --wait-for-release scrapes the releases
+// index.
+const minPollInterval = 10 * time.Second
+
+// exactVersion returns the version required by one of the constraints
+// written as "= X" or "X", or nil if there is none.
+func exactVersion(constraints version.Constraints) *version.Version {
+	for _, constraint := range constraints {
+		raw := strings.TrimSpace(constraint.String())
+		raw = strings.TrimSpace(strings.TrimPrefix(raw, "="))
+
+		if v, err := version.NewVersion(raw); err == nil {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// waitForRelease polls the releases index, bypassing the index cache, until
+// the exact version required by the constraints is released and installs it,
+// giving up after timeout.
+func waitForRelease(managerOpts tvm.Options, constraints version.Constraints, timeout time.Duration, interval time.Duration) (*version.Version, error) {
+	exact := exactVersion(constraints)
+
+	if exact == nil {
+		return nil, fmt.Errorf("%s, and --wait-for-release only waits for an exact version", tvm.ErrNoMatchingVersion)
+	}
+
+	if interval < minPollInterval {
+		interval = minPollInterval
+	}
+
+	managerOpts.IndexCacheTTL = 0
+	m := tvm.New(managerOpts)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		remaining := time.Until(deadline)
+
+		if remaining <= 0 {
+			return nil, fmt.Errorf("Terraform %s was not released within %s", exact, timeout)
+		}
+
+		wait := interval
+
+		if wait > remaining {
+			wait = remaining
+		}
+
+		fmt.Printf("Terraform %s is not available yet, checking again in %s (%s left)\n", exact, wait.Round(time.Second), remaining.Round(time.Second))
+		time.Sleep(wait)
+
+		tfVersion, err := m.Install(context.Background(), constraints)
+
+		if err != tvm.ErrNoMatchingVersion {
+			return tfVersion, err
+		}
+	}
+}
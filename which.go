@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hashicorp/go-version"
+)
+
+type whichOptions struct {
+	All    bool
+	JSON   bool
+	GitRef string
+}
+
+// which prints the path of the binary exec would run in the current
+// directory or, with --all, every installed version satisfying the
+// constraints, the one exec would run being marked with a star. With
+// --git-ref, the constraints are those of the configuration at that ref.
+func which(opts whichOptions) {
+	m := newManager()
+
+	dir, cleanup, err := constraintsDir(opts.GitRef)
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	constraints, err := m.Constraints(dir)
+
+	cleanup()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	versions, err := m.Match(constraints)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(versions) == 0 {
+		fmt.Printf("None of the installed Terraform versions matched the constraints\n")
+		os.Exit(1)
+	}
+
+	if !opts.All {
+		versions = versions[:1]
+	}
+
+	entries := make([]listEntry, len(versions))
+
+	for i, v := range versions {
+		entries[i] = listEntry{
+			Version:  v.String(),
+			Path:     m.BinaryPath(v),
+			Selected: i == 0,
+		}
+	}
+
+	if opts.JSON {
+		if opts.All {
+			printJSONList(entries, nil)
+		} else {
+			printJSONEntry(entries[0])
+		}
+
+		return
+	}
+
+	if !opts.All {
+		fmt.Println(entries[0].Path)
+
+		return
+	}
+
+	for _, entry := range entries {
+		marker := " "
+
+		if entry.Selected {
+			marker = "*"
+		}
+
+		fmt.Printf("%s %-10s %s\n", marker, entry.Version, entry.Path)
+	}
+}
+
+// installedPath prints the path of the binary of the exact version given,
+// without resolving constraints or using the network, and exits with a
+// non-zero status if it isn't installed.
+func installedPath(rawVersion string) {
+	v, err := version.NewVersion(rawVersion)
+
+	if err != nil {
+		fmt.Printf("Invalid version %q: %s\n", rawVersion, err)
+		os.Exit(1)
+	}
+
+	binPath := newManager().BinaryPath(v)
+
+	if _, err := os.Stat(binPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Terraform version %s is not installed\n", v)
+		os.Exit(1)
+	}
+
+	fmt.Println(binPath)
+}